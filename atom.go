@@ -1,148 +1,241 @@
 package rss
 
 import (
-	"bytes"
 	"encoding/xml"
 	"fmt"
-	"time"
+	"io"
+	"strings"
 )
 
-func parseAtom(data []byte) (*Feed, error) {
-	warnings := false
+func parseAtom(r io.Reader, p *Parser) (*Feed, error) {
 	feed := atomFeed{}
-	p := xml.NewDecoder(bytes.NewReader(data))
-	p.CharsetReader = charsetReader
-	err := p.Decode(&feed)
-	if err != nil {
-		return nil, err
-	}
-
-	out := new(Feed)
-	out.Title = feed.Title
-	out.Description = feed.Description
-	for _, link := range feed.Link {
-		if link.Rel == "alternate" || link.Rel == "" {
-			out.Link = link.Href
-			break
-		}
-	}
-	out.Image = feed.Image.Image()
-	out.Refresh = time.Now().Add(DefaultRefreshInterval)
-
-	out.Items = make([]*Item, 0, len(feed.Items))
-	out.ItemMap = make(map[string]struct{})
-
-	// Process items.
-	for _, item := range feed.Items {
-
-		// Skip items already known.
-		if _, ok := out.ItemMap[item.ID]; ok {
-			continue
+	feed.Items = func(d *xml.Decoder, start xml.StartElement) error {
+		item := atomItem{}
+		if err := d.DecodeElement(&item, &start); err != nil {
+			return err
 		}
 
 		next := new(Item)
-		next.Title = item.Title
-		next.Summary = item.Summary
-		next.Content = item.Content.RAWContent
-
-		if date, err := parseDate(item.Date); err == nil {
-			next.Date = date
-			next.DateValid = true
-		} else if date, err := parseDate(item.Published); err == nil {
-			next.Date = date
-			next.DateValid = true
+		next.Title = item.Title.Text()
+		next.Summary, next.SummaryType = item.Summary.Value()
+		next.Content, next.ContentType = item.Content.Value()
+		next.ContentURL = resolveURL(item.Content.Base, item.Content.Src)
+		next.SummaryType = contentType(next.Summary, next.SummaryType)
+		if next.ContentURL == "" {
+			next.ContentType = contentType(next.Content, next.ContentType)
 		}
+		if next.SummaryType == ContentTypeHTML {
+			next.Summary = resolveHTML(next.Summary, item.Summary.Base)
+		}
+		if next.ContentType == ContentTypeHTML {
+			next.Content = resolveHTML(next.Content, item.Content.Base)
+		}
+		next.Rights = item.Rights.Text()
+
+		p.itemDate(next, "updated", item.Date)
+		p.itemDate(next, "published", item.Published)
 
 		next.ID = item.ID
-		for _, link := range item.Links {
-			if link.Rel == "alternate" || link.Rel == "" {
-				next.Link = link.Href
-			} else {
+		next.Authors = atomPeople(item.Authors)
+		next.Contributors = atomPeople(item.Contributors)
+		if len(next.Authors) == 0 {
+			// Entries inherit the authors of the feed.
+			next.Authors = atomPeople(feed.Authors)
+		}
+		next.Links = atomLinks(p, p.items, item.ID, item.Links)
+		// Links of other relations are only in next.Links.
+		for i, link := range item.Links {
+			switch strings.ToLower(link.Rel) {
+			case "alternate", "":
+				next.Link = link.URL()
+			case "enclosure":
 				next.Enclosures = append(next.Enclosures, &Enclosure{
-					URL:    link.Href,
+					URL:    link.URL(),
 					Type:   link.Type,
-					Length: link.Length,
+					Title:  link.Title,
+					Length: next.Links[i].Length,
 				})
 			}
 		}
+		next.Media = item.mrssElements.media(p, item.ID)
+		next.Enclosures = item.mrssElements.enclosures(p, item.ID, next.Enclosures, next.Media)
+		if next.Media != nil && len(next.Media.Thumbnails) > 0 {
+			image := *next.Media.Thumbnails[0]
+			next.Image = &image
+		}
 		next.Read = false
 
 		if next.ID == "" {
-			if debug {
-				fmt.Printf("[w] Item %q has no ID and will be ignored.\n", next.Title)
-				fmt.Printf("[w] %#v\n", item)
-			}
-			warnings = true
-			continue
+			p.Drop(WarnMissingID, "id", fmt.Sprintf("entry %q has no ID", next.Title))
+			return nil
 		}
 
-		if _, ok := out.ItemMap[next.ID]; ok {
-			if debug {
-				fmt.Printf("[w] Item %q has duplicate ID.\n", next.Title)
-				fmt.Printf("[w] %#v\n", next)
-			}
-			warnings = true
-			continue
-		}
+		// Anything else is resolved against the entry's xml:base.
+		p.itemBase = item.Base
+		return p.Emit(next)
+	}
 
-		out.Items = append(out.Items, next)
-		out.ItemMap[next.ID] = struct{}{}
-		out.Unread++
+	err := p.decodeXML(r, &feed, nsAtomAny, atomSpaces)
+	if err != nil {
+		return nil, err
 	}
 
-	if warnings && debug {
-		fmt.Printf("[i] Encountered warnings:\n%s\n", data)
+	out := p.out
+	out.Title = feed.Title.Text()
+	out.Description, _ = feed.Description.Value()
+	if out.Description == "" {
+		out.Description, _ = feed.Tagline.Value()
+	}
+	out.Rights = feed.Rights.Text()
+	if out.Rights == "" {
+		out.Rights = feed.Copyright.Text()
 	}
+	out.Authors = atomPeople(feed.Authors)
+	out.Contributors = atomPeople(feed.Contributors)
+	out.Author = authorName(out.Authors)
+	out.Links = atomLinks(p, -1, "", feed.Link)
+	for _, link := range feed.Link {
+		if (link.Rel == "alternate" || link.Rel == "") && out.Link == "" {
+			out.Link = link.URL()
+		} else if link.Rel == "self" && out.SelfURL == "" {
+			out.SelfURL = link.URL()
+		}
+	}
+	out.Hubs = hubLinks(out.Links)
+	out.NextURL = linkHref(out.Links, "next")
+	out.Image = feed.Image.Image()
 
 	return out, nil
 }
 
-func parseDate(date string) (time.Time, error) {
-	if date == "" {
-		return time.Time{}, fmt.Errorf("no date specified")
-	}
-	return parseTime(date)
-}
-
+// RAWContent holds the raw XML inside an element.
+//
+// Deprecated: It is no longer used to parse Atom content, which is decoded
+// according to its type.
 type RAWContent struct {
 	RAWContent string `xml:",innerxml"`
 }
 
 type atomFeed struct {
-	XMLName     xml.Name   `xml:"feed"`
-	Title       string     `xml:"title"`
-	Description string     `xml:"subtitle"`
-	Link        []atomLink `xml:"link"`
-	Image       atomImage  `xml:"image"`
-	Items       []atomItem `xml:"entry"`
-	Updated     string     `xml:"updated"`
+	XMLName     xml.Name    `xml:"feed"`
+	Title       atomText    `xml:"urn:atom title"`
+	Description atomText    `xml:"urn:atom subtitle"`
+	Tagline     atomText    `xml:"urn:atom tagline"` // Atom 0.3 subtitle.
+	Rights      atomText    `xml:"urn:atom rights"`
+	Copyright   atomText    `xml:"urn:atom copyright"` // Atom 0.3 rights.
+	Link        []atomLink  `xml:"urn:atom link"`
+	Image       atomImage   `xml:"urn:atom image"`
+	Items       elementFunc `xml:"urn:atom entry"`
+	Updated     string      `xml:"urn:atom updated"`
+	// Authors that appear before an entry are inherited by it.
+	Authors      []atomPerson `xml:"urn:atom author"`
+	Contributors []atomPerson `xml:"urn:atom contributor"`
 }
 
 type atomItem struct {
-	XMLName   xml.Name   `xml:"entry"`
-	Title     string     `xml:"title"`
-	Summary   string     `xml:"summary"`
-	Content   RAWContent `xml:"content"`
-	Links     []atomLink `xml:"link"`
-	Date      string     `xml:"updated"`
-	Published string     `xml:"published"`
-	DateValid bool
-	ID        string `xml:"id"`
+	XMLName      xml.Name   `xml:"entry"`
+	Base         string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title        atomText   `xml:"urn:atom title"`
+	Summary      atomText   `xml:"urn:atom summary"`
+	Content      atomText   `xml:"urn:atom content"`
+	Rights       atomText   `xml:"urn:atom rights"`
+	Links        []atomLink `xml:"urn:atom link"`
+	Date         string     `xml:"urn:atom updated"`
+	Published    string     `xml:"urn:atom published"`
+	DateValid    bool
+	ID           string       `xml:"urn:atom id"`
+	Authors      []atomPerson `xml:"urn:atom author"`
+	Contributors []atomPerson `xml:"urn:atom contributor"`
+	mrssElements
+}
+
+// atomPerson is an Atom person construct. Atom 0.3 uses <url> in place
+// of <uri>.
+type atomPerson struct {
+	Name  string `xml:"urn:atom name"`
+	Email string `xml:"urn:atom email"`
+	URI   string `xml:"urn:atom uri"`
+	URL   string `xml:"urn:atom url"`
+}
+
+func atomPeople(in []atomPerson) []*Person {
+	var out []*Person
+	for _, p := range in {
+		uri := p.URI
+		if uri == "" {
+			uri = p.URL
+		}
+		out = appendPeople(out, &Person{
+			Name:  strings.TrimSpace(p.Name),
+			Email: strings.TrimSpace(p.Email),
+			URI:   strings.TrimSpace(uri),
+		})
+	}
+	return out
 }
 
 type atomImage struct {
 	XMLName xml.Name `xml:"image"`
-	Title   string   `xml:"title"`
-	URL     string   `xml:"url"`
-	Height  int      `xml:"height"`
-	Width   int      `xml:"width"`
+	Title   string   `xml:"urn:atom title"`
+	URL     string   `xml:"urn:atom url"`
+	Height  int      `xml:"urn:atom height"`
+	Width   int      `xml:"urn:atom width"`
 }
 
 type atomLink struct {
-	Href   string `xml:"href,attr"`
-	Rel    string `xml:"rel,attr"`
-	Type   string `xml:"type,attr"`
-	Length uint   `xml:"length,attr"`
+	Base     string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Href     string `xml:"href,attr"`
+	Rel      string `xml:"rel,attr"`
+	Type     string `xml:"type,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Title    string `xml:"title,attr"`
+	Length   string `xml:"length,attr"` // Often malformed, so parsed leniently.
+}
+
+// URL returns the link's href, resolved against the xml:base in effect.
+func (l *atomLink) URL() string {
+	return resolveURL(l.Base, l.Href)
+}
+
+// Link returns the link as a typed link. Links without a rel are
+// alternate links. A bad length is reported through p for the given item,
+// or -1 for the feed.
+func (l *atomLink) Link(p *Parser, item int, id string) *Link {
+	rel := l.Rel
+	if rel == "" {
+		rel = "alternate"
+	}
+	length := uint(p.parseInt(item, id, "length", l.Length))
+	return &Link{Href: l.URL(), Rel: rel, Type: l.Type, HrefLang: l.HrefLang, Title: l.Title, Length: length}
+}
+
+func atomLinks(p *Parser, item int, id string, in []atomLink) []*Link {
+	var out []*Link
+	for i := range in {
+		out = append(out, in[i].Link(p, item, id))
+	}
+	return out
+}
+
+// linkHref returns the URL of the first link of relation rel.
+func linkHref(links []*Link, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// hubLinks returns the rel="hub" links, which are WebSub hubs.
+func hubLinks(links []*Link) []*Hub {
+	var out []*Hub
+	for _, link := range links {
+		if link.Rel == "hub" {
+			out = append(out, &Hub{Type: "WebSub", URL: link.Href})
+		}
+	}
+	return out
 }
 
 func (a *atomImage) Image() *Image {
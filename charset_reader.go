@@ -12,6 +12,10 @@ func charsetReader(charset string, input io.Reader) (io.Reader, error) {
 	switch {
 	case isCharsetUTF8(charset):
 		return input, nil
+	case isCharsetUTF16(charset):
+		// UTF-16 documents must start with a byte order mark, and
+		// have already been transcoded to UTF-8 when it was found.
+		return input, nil
 	case isCharsetISO88591(charset):
 		return newCharsetISO88591(input), nil
 	default:
@@ -41,3 +45,12 @@ func isCharsetUTF8(charset string) bool {
 	}
 	return isCharset(charset, names)
 }
+
+func isCharsetUTF16(charset string) bool {
+	names := []string{
+		"UTF-16",
+		"UTF-16BE",
+		"UTF-16LE",
+	}
+	return isCharset(charset, names)
+}
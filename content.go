@@ -0,0 +1,275 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"html"
+	"mime"
+	"regexp"
+	"strings"
+)
+
+// Content types of Item.Content and Item.Summary. Other content is given
+// by its MIME type.
+const (
+	ContentTypeText = "text" // Plain text.
+	ContentTypeHTML = "html" // An HTML fragment.
+)
+
+// contentType returns contentType if value is set, and "" otherwise.
+func contentType(value, contentType string) string {
+	if value == "" {
+		return ""
+	}
+	return contentType
+}
+
+// atomText is an Atom text construct, as defined by RFC 4287 section 3.1,
+// or the content of an entry, as defined by section 4.1.3. The mode
+// attribute is from Atom 0.3.
+type atomText struct {
+	Type     string
+	Mode     string
+	Src      string
+	Base     string // The xml:base in effect.
+	Chardata string // The text directly inside the element.
+	InnerXML string // Everything inside the element, as markup.
+}
+
+// UnmarshalXML rebuilds the markup inside the element from its tokens,
+// rather than using innerxml, which is empty when the decoder reads from
+// a token stream.
+func (t *atomText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch {
+		case a.Name.Space == nsXML && a.Name.Local == "base":
+			t.Base = a.Value
+		case a.Name.Space != "":
+		case a.Name.Local == "type":
+			t.Type = a.Value
+		case a.Name.Local == "mode":
+			t.Mode = a.Value
+		case a.Name.Local == "src":
+			t.Src = a.Value
+		}
+	}
+
+	var text, inner strings.Builder
+	var void []bool // For each open element, whether it is void in HTML.
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			isVoid := htmlVoidElements[strings.ToLower(tok.Name.Local)]
+			void = append(void, isVoid)
+			inner.WriteString("<" + tok.Name.Local)
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" || a.Name.Space == nsXML {
+					continue
+				}
+				inner.WriteString(" " + a.Name.Local + `="` + attrEscaper.Replace(a.Value) + `"`)
+			}
+			if isVoid {
+				inner.WriteString("/>")
+			} else {
+				inner.WriteString(">")
+			}
+		case xml.EndElement:
+			if len(void) == 0 {
+				t.Chardata = text.String()
+				t.InnerXML = inner.String()
+				return nil
+			}
+			if !void[len(void)-1] {
+				inner.WriteString("</" + tok.Name.Local + ">")
+			}
+			void = void[:len(void)-1]
+		case xml.CharData:
+			if len(void) == 0 {
+				text.Write(tok)
+			}
+			inner.WriteString(textEscaper.Replace(string(tok)))
+		case xml.Comment:
+			inner.WriteString("<!--" + string(tok) + "-->")
+		}
+	}
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+)
+
+// htmlVoidElements are the HTML elements that have no end tag.
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rssText is the text of an RSS element that holds HTML, such as
+// <description>. HTML left unescaped inside it, which lenient parsing
+// lets through, is kept as markup rather than reduced to its text.
+type rssText string
+
+func (t *rssText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text atomText
+	if err := text.UnmarshalXML(d, start); err != nil {
+		return err
+	}
+	*t = rssText(text.Chardata)
+	if hasChildElements(text.InnerXML) {
+		*t = rssText(text.InnerXML)
+	}
+	return nil
+}
+
+// Value returns the decoded text and its content type, which is
+// ContentTypeText, ContentTypeHTML or a MIME type. XHTML is returned as
+// HTML. It returns "" for out-of-line content, which is at t.Src.
+func (t *atomText) Value() (value, contentType string) {
+	if t.Src != "" {
+		return "", t.Type
+	}
+
+	contentType = atomContentType(t.Type)
+
+	switch strings.ToLower(t.Mode) {
+	case "escaped":
+		return t.Chardata, contentType
+	case "base64":
+		return decodeBase64(t.Chardata), contentType
+	}
+
+	switch {
+	case contentType == ContentTypeText:
+		// Text must not contain markup, but it is kept if it does.
+		if hasChildElements(t.InnerXML) {
+			return strings.TrimSpace(t.InnerXML), ContentTypeHTML
+		}
+		return t.Chardata, contentType
+	case contentType == ContentTypeHTML:
+		// HTML is escaped, but XHTML and Atom 0.3 XML mode are inline.
+		if strings.Contains(strings.ToLower(t.Type), "xhtml") || strings.EqualFold(t.Mode, "xml") {
+			return xhtmlContent(t.InnerXML), contentType
+		}
+		return t.Chardata, contentType
+	case isXMLMediaType(contentType):
+		return strings.TrimSpace(t.InnerXML), contentType
+	case strings.HasPrefix(contentType, "text/"):
+		return t.Chardata, contentType
+	}
+	return decodeBase64(t.Chardata), contentType
+}
+
+// Text returns the text construct as plain text, for use in titles.
+func (t *atomText) Text() string {
+	value, contentType := t.Value()
+	if contentType == ContentTypeHTML {
+		return htmlToText(value)
+	}
+	return strings.TrimSpace(value)
+}
+
+// atomContentType maps the type attribute of Atom 1.0 or 0.3 content to a
+// content type.
+func atomContentType(typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if mediaType, _, err := mime.ParseMediaType(typ); err == nil {
+		typ = mediaType
+	}
+
+	switch typ {
+	case "", "text", "text/plain":
+		return ContentTypeText
+	case "html", "text/html", "xhtml", "application/xhtml+xml":
+		return ContentTypeHTML
+	}
+	return typ
+}
+
+func isXMLMediaType(typ string) bool {
+	typ = strings.ToLower(typ)
+	return strings.HasSuffix(typ, "+xml") || strings.HasSuffix(typ, "/xml")
+}
+
+func decodeBase64(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" \t\r\n", r) {
+			return -1
+		}
+		return r
+	}, s)
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// hasChildElements reports whether the XML fragment s contains elements.
+func hasChildElements(s string) bool {
+	if !strings.Contains(s, "<") {
+		return false
+	}
+	d := xml.NewDecoder(strings.NewReader(s))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return false
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			return true
+		}
+	}
+}
+
+// xhtmlContent returns the markup inside the <div> that wraps XHTML
+// content. If there is no such <div>, s is returned as it is.
+func xhtmlContent(s string) string {
+	d := xml.NewDecoder(strings.NewReader(s))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return strings.TrimSpace(s)
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) > 0 {
+				return strings.TrimSpace(s)
+			}
+			continue
+		case xml.StartElement:
+			if tok.Name.Local != "div" {
+				return strings.TrimSpace(s)
+			}
+		default:
+			continue
+		}
+
+		start := d.InputOffset()
+		if err := d.Skip(); err != nil {
+			return strings.TrimSpace(s)
+		}
+		inner := s[start:d.InputOffset()]
+		if i := strings.LastIndex(inner, "</"); i >= 0 {
+			inner = inner[:i]
+		}
+		return strings.TrimSpace(inner)
+	}
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// htmlToText strips the tags from an HTML fragment and decodes its
+// entities.
+func htmlToText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(s, "")))
+}
@@ -0,0 +1,114 @@
+package rss
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAtomTextConstructs(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title type="html">Fish &amp;amp; &lt;b&gt;Chips&lt;/b&gt;</title>
+<subtitle type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">All <em>about</em> fish</div></subtitle>
+<rights>© 2024 Fish &amp; Co</rights>
+<entry>
+<id>html</id>
+<title type="text">1 &lt; 2</title>
+<summary type="text">Plain &lt;summary&gt;</summary>
+<content type="html">&lt;p&gt;Escaped &amp;amp; HTML&lt;/p&gt;</content>
+</entry>
+<entry>
+<id>xhtml</id>
+<title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">An <b>XHTML</b> title</div></title>
+<content type="xhtml">
+  <div xmlns="http://www.w3.org/1999/xhtml"><p>Inline &amp; <br/>XHTML</p></div>
+</content>
+</entry>
+<entry>
+<id>src</id>
+<content type="video/mp4" src="https://example.com/video.mp4"/>
+</entry>
+<entry>
+<id>base64</id>
+<content type="application/octet-stream">aGVsbG8g
+d29ybGQ=</content>
+</entry>
+<entry>
+<id>xml</id>
+<content type="application/xml"><data><value>1</value></data></content>
+</entry>
+</feed>`)
+
+	feed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if want := "Fish & Chips"; feed.Title != want {
+		t.Errorf("Expected title %q, got %q", want, feed.Title)
+	}
+	if want := "All <em>about</em> fish"; feed.Description != want {
+		t.Errorf("Expected subtitle %q, got %q", want, feed.Description)
+	}
+	if want := "© 2024 Fish & Co"; feed.Rights != want {
+		t.Errorf("Expected rights %q, got %q", want, feed.Rights)
+	}
+
+	tests := []Item{{
+		Title:       "1 < 2",
+		Summary:     "Plain <summary>",
+		SummaryType: ContentTypeText,
+		Content:     "<p>Escaped &amp; HTML</p>",
+		ContentType: ContentTypeHTML,
+	}, {
+		Title:       "An XHTML title",
+		Content:     "<p>Inline &amp; <br/>XHTML</p>",
+		ContentType: ContentTypeHTML,
+	}, {
+		ContentType: "video/mp4",
+		ContentURL:  "https://example.com/video.mp4",
+	}, {
+		Content:     "hello world",
+		ContentType: "application/octet-stream",
+	}, {
+		Content:     "<data><value>1</value></data>",
+		ContentType: "application/xml",
+	}}
+
+	if len(feed.Items) != len(tests) {
+		t.Fatalf("Expected %d items, got %d", len(tests), len(feed.Items))
+	}
+	for i, want := range tests {
+		got := feed.Items[i]
+		if got.Title != want.Title || got.Summary != want.Summary || got.SummaryType != want.SummaryType ||
+			got.Content != want.Content || got.ContentType != want.ContentType || got.ContentURL != want.ContentURL {
+			t.Errorf("Item %s: got title %q, summary %q (%s), content %q (%s) at %q; want title %q, summary %q (%s), content %q (%s) at %q",
+				got.ID, got.Title, got.Summary, got.SummaryType, got.Content, got.ContentType, got.ContentURL,
+				want.Title, want.Summary, want.SummaryType, want.Content, want.ContentType, want.ContentURL)
+		}
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	tests := map[string]string{
+		"rss_2.0_content_encoded": ContentTypeHTML,
+		"atom_1.0":                ContentTypeText,
+		"atom_1.0_html":           ContentTypeHTML,
+		"jsonfeed_v1":             ContentTypeHTML,
+	}
+
+	for name, want := range tests {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+		feed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", name, err)
+		}
+		if got := feed.Items[0].ContentType; got != want {
+			t.Errorf("%s: expected content type %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Names of the supported feed formats, as used in Format.Name.
+const (
+	FormatRSS      = "rss"
+	FormatAtom     = "atom"
+	FormatJSONFeed = "jsonfeed"
+)
+
+// Format identifies the format a feed was parsed from.
+type Format struct {
+	Name    string `json:"name"`    // One of the Format constants.
+	Version string `json:"version"` // Such as "2.0" for RSS, or "1.1" for JSON Feed. May be empty.
+}
+
+func (f Format) String() string {
+	if f.Version == "" {
+		return f.Name
+	}
+	return f.Name + " " + f.Version
+}
+
+// Namespaces that identify the root element of a feed.
+const (
+	nsRDF     = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsRSS10   = "http://purl.org/rss/1.0/"
+	nsRSS090  = "http://my.netscape.com/rdf/simple/0.9/"
+	nsAtom    = "http://www.w3.org/2005/Atom"
+	nsAtom03  = "http://purl.org/atom/ns#"
+	jsonFeedV = "https://jsonfeed.org/version/"
+)
+
+// nsXML is the namespace of the xml: prefix, as in xml:base.
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
+// skipBOM consumes any byte order mark at the start of br, and returns a
+// reader of the document as UTF-8.
+func skipBOM(br *bufio.Reader) io.Reader {
+	head, _ := br.Peek(3)
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		br.Discard(len(bomUTF8))
+	case bytes.HasPrefix(head, bomUTF16BE):
+		br.Discard(len(bomUTF16BE))
+		return &utf16Reader{r: br, order: binary.BigEndian}
+	case bytes.HasPrefix(head, bomUTF16LE):
+		br.Discard(len(bomUTF16LE))
+		return &utf16Reader{r: br, order: binary.LittleEndian}
+	}
+	return br
+}
+
+// detectRSS recognises RSS 0.90 and 1.0, which are RDF documents, and the
+// other versions of RSS, whose root element is <rss>.
+func detectRSS(prefix []byte, contentType string) (string, bool) {
+	if root, ok := xmlRoot(prefix); ok {
+		switch {
+		case root.Name.Local == "rss":
+			if version := attr(root, "version"); version != "" {
+				return version, true
+			}
+			return "2.0", true
+		case root.Name.Local == "RDF" && root.Name.Space == nsRDF:
+			for _, a := range root.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					switch a.Value {
+					case nsRSS10:
+						return "1.0", true
+					case nsRSS090:
+						return "0.90", true
+					}
+				}
+			}
+		}
+	}
+
+	switch mediaType(contentType) {
+	case "application/rss+xml":
+		return "2.0", true
+	case "application/rdf+xml":
+		return "1.0", true
+	}
+	return "", false
+}
+
+func detectAtom(prefix []byte, contentType string) (string, bool) {
+	if root, ok := xmlRoot(prefix); ok && root.Name.Local == "feed" {
+		switch root.Name.Space {
+		case nsAtom:
+			return "1.0", true
+		case nsAtom03:
+			return "0.3", true
+		case "":
+			return "", true
+		}
+	}
+
+	if mediaType(contentType) == "application/atom+xml" {
+		return "1.0", true
+	}
+	return "", false
+}
+
+// detectJSONFeed looks for the version key in the top-level object of a
+// JSON Feed. If prefix ends before the version is found, the document is
+// assumed to be a JSON Feed, as the rest of the object may yet hold it.
+func detectJSONFeed(prefix []byte, contentType string) (string, bool) {
+	if version, ok := jsonFeedVersion(prefix); ok {
+		return version, true
+	}
+	if mediaType(contentType) == "application/feed+json" {
+		return "", true
+	}
+	return "", false
+}
+
+func jsonFeedVersion(prefix []byte) (string, bool) {
+	d := json.NewDecoder(bytes.NewReader(prefix))
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return "", false
+	}
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		var value json.RawMessage
+		if err := d.Decode(&value); err != nil {
+			break
+		}
+		if key, _ := tok.(string); key != "version" {
+			continue
+		}
+
+		var version string
+		if err := json.Unmarshal(value, &version); err != nil || !strings.HasPrefix(version, jsonFeedV) {
+			return "", false
+		}
+		return strings.TrimPrefix(version, jsonFeedV), true
+	}
+
+	// The whole object has been read without finding a version.
+	if _, err := d.Token(); err == nil {
+		return "", false
+	}
+	return "", true
+}
+
+// xmlRoot returns the root element of the XML document starting with
+// prefix.
+func xmlRoot(prefix []byte) (xml.StartElement, bool) {
+	d := xml.NewDecoder(bytes.NewReader(prefix))
+	d.CharsetReader = charsetReader
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, false
+		}
+		if root, ok := tok.(xml.StartElement); ok {
+			return root, true
+		}
+	}
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// utf16Reader transcodes UTF-16 read from r into UTF-8.
+type utf16Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	in    []byte // Undecoded input, at most one code unit and a byte.
+	out   []byte // Decoded output not yet read.
+	err   error
+}
+
+func (u *utf16Reader) Read(b []byte) (int, error) {
+	for len(u.out) == 0 && u.err == nil {
+		buf := make([]byte, 4096)
+		n, err := u.r.Read(buf)
+		u.in = append(u.in, buf[:n]...)
+		u.err = err
+
+		for len(u.in) >= 2 {
+			r1 := rune(u.order.Uint16(u.in))
+			if utf16.IsSurrogate(r1) {
+				if len(u.in) < 4 {
+					break
+				}
+				r1 = utf16.DecodeRune(r1, rune(u.order.Uint16(u.in[2:])))
+				u.in = u.in[2:]
+			}
+			u.in = u.in[2:]
+			var buf [utf8.UTFMax]byte
+			u.out = append(u.out, buf[:utf8.EncodeRune(buf[:], r1)]...)
+		}
+	}
+
+	n := copy(b, u.out)
+	u.out = u.out[n:]
+	if len(u.out) == 0 {
+		return n, u.err
+	}
+	return n, nil
+}
@@ -0,0 +1,126 @@
+package rss
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := map[string]Format{
+		"rss_0.91":          {Name: FormatRSS, Version: "0.91"},
+		"rss_0.92":          {Name: FormatRSS, Version: "0.92"},
+		"rss_1.0":           {Name: FormatRSS, Version: "2.0"},
+		"rss_1.0_enclosure": {Name: FormatRSS, Version: "1.0"},
+		"rss_2.0":           {Name: FormatRSS, Version: "2.0"},
+		"atom_1.0":          {Name: FormatAtom, Version: "1.0"},
+		"jsonfeed_v1":       {Name: FormatJSONFeed, Version: "1"},
+	}
+
+	for test, want := range tests {
+		name := filepath.Join("testdata", test)
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		feed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", name, err)
+		}
+
+		if feed.Format != want {
+			t.Errorf("%s: got format %v, want %v", name, feed.Format, want)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		want        Format
+	}{{
+		name: "atom mentioning rss",
+		data: `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><entry><content>Subscribe to &lt;rss&gt; <rss/></content></entry></feed>`,
+		want: Format{Name: FormatAtom, Version: "1.0"},
+	}, {
+		name: "rss with comment and stylesheet",
+		data: `<?xml version="1.0"?><?xml-stylesheet href="s.xsl"?><!-- <feed> --><rss version="2.0"><channel/></rss>`,
+		want: Format{Name: FormatRSS, Version: "2.0"},
+	}, {
+		name: "rss 1.0",
+		data: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel/></rdf:RDF>`,
+		want: Format{Name: FormatRSS, Version: "1.0"},
+	}, {
+		name: "json feed 1.1",
+		data: `{"title": "<rss", "version": "https://jsonfeed.org/version/1.1", "items": []}`,
+		want: Format{Name: FormatJSONFeed, Version: "1.1"},
+	}, {
+		name: "atom without namespace",
+		data: `<feed><title>Feed</title></feed>`,
+		want: Format{Name: FormatAtom},
+	}, {
+		name:        "content type fallback",
+		data:        `garbage`,
+		contentType: "application/atom+xml; charset=utf-8",
+		want:        Format{Name: FormatAtom, Version: "1.0"},
+	}, {
+		name:        "document wins over content type",
+		data:        `<rss version="2.0"/>`,
+		contentType: "application/atom+xml",
+		want:        Format{Name: FormatRSS, Version: "2.0"},
+	}}
+
+	for _, tt := range tests {
+		_, got, ok := detectFormat([]byte(tt.data), tt.contentType)
+		if !ok || got != tt.want {
+			t.Errorf("%s: got %v (%v), want %v", tt.name, got, ok, tt.want)
+		}
+	}
+
+	for _, data := range []string{`<html><body>rss</body></html>`, `{"version": "2.0"}`, `{"items": []}`, `<feed xmlns="urn:other"/>`} {
+		if _, got, ok := detectFormat([]byte(data), "text/html"); ok {
+			t.Errorf("%q: expected unknown format, got %v", data, got)
+		}
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	data := []byte(`<html><body><a href="/feed">Our <rss> feed</a></body></html>`)
+	if _, err := Parse(data); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestParseByteOrderMark(t *testing.T) {
+	name := filepath.Join("testdata", "atom_1.0")
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Reading %s: %v", name, err)
+	}
+
+	utf8 := append([]byte{0xEF, 0xBB, 0xBF}, data...)
+
+	units := utf16.Encode([]rune(string(data)))
+	utf16LE := make([]byte, 2+2*len(units))
+	utf16LE[0], utf16LE[1] = 0xFF, 0xFE
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(utf16LE[2+2*i:], u)
+	}
+
+	for encoding, data := range map[string][]byte{"UTF-8": utf8, "UTF-16LE": utf16LE} {
+		feed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: parsing %s: %v", encoding, name, err)
+		}
+
+		if feed.Title != "Titel des Weblogs" {
+			t.Errorf("%s: got title %q", encoding, feed.Title)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package rss
+
+import "strings"
+
+// dcElements are the Dublin Core elements, which RSS feeds use for
+// metadata that RSS lacks, and RSS 1.0 feeds in place of RSS 2.0 elements.
+// See https://www.dublincore.org/specifications/dublin-core/dces/.
+type dcElements struct {
+	DCTitle       string   `xml:"http://purl.org/dc/elements/1.1/ title"`
+	DCDescription string   `xml:"http://purl.org/dc/elements/1.1/ description"`
+	Creators      []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Contributors  []string `xml:"http://purl.org/dc/elements/1.1/ contributor"`
+	Subjects      []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Publisher     string   `xml:"http://purl.org/dc/elements/1.1/ publisher"`
+	Date          string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Identifier    string   `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+	DCLanguage    string   `xml:"http://purl.org/dc/elements/1.1/ language"`
+	DCRights      string   `xml:"http://purl.org/dc/elements/1.1/ rights"`
+}
+
+// item fills in the fields of item left empty by RSS's own elements, and
+// adds the creators, contributors and subjects.
+func (dc *dcElements) item(item *Item) {
+	if item.Title == "" {
+		item.Title = strings.TrimSpace(dc.DCTitle)
+	}
+	if item.Summary == "" && dc.DCDescription != "" {
+		item.Summary = dc.DCDescription
+		item.SummaryType = ContentTypeText
+	}
+	item.Authors = dc.people(item.Authors, dc.Creators)
+	item.Contributors = dc.people(item.Contributors, dc.Contributors)
+	item.Categories = dc.subjects(item.Categories)
+	if item.Rights == "" {
+		item.Rights = strings.TrimSpace(dc.DCRights)
+	}
+	if item.Language == "" {
+		item.Language = strings.TrimSpace(dc.DCLanguage)
+	}
+}
+
+// feed is like item, for the channel.
+func (dc *dcElements) feed(feed *Feed) {
+	if feed.Title == "" {
+		feed.Title = strings.TrimSpace(dc.DCTitle)
+	}
+	if feed.Description == "" {
+		feed.Description = dc.DCDescription
+	}
+	feed.Authors = dc.people(feed.Authors, dc.Creators)
+	feed.Contributors = dc.people(feed.Contributors, dc.Contributors)
+	feed.Categories = dc.subjects(feed.Categories)
+	if feed.Rights == "" {
+		feed.Rights = strings.TrimSpace(dc.DCRights)
+	}
+	if feed.Language == "" {
+		feed.Language = strings.TrimSpace(dc.DCLanguage)
+	}
+	feed.Publisher = strings.TrimSpace(dc.Publisher)
+}
+
+func (dc *dcElements) people(out []*Person, names []string) []*Person {
+	for _, name := range names {
+		out = appendPeople(out, parsePerson(name))
+	}
+	return out
+}
+
+func (dc *dcElements) subjects(out []string) []string {
+	for _, subject := range dc.Subjects {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			out = append(out, subject)
+		}
+	}
+	return out
+}
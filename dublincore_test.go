@@ -0,0 +1,80 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+const dublinCoreFeed = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel rdf:about="https://library.example/new">
+	<title>New acquisitions</title>
+	<link>https://library.example/new</link>
+	<description>Books added this week</description>
+	<dc:creator>Cataloguing Team</dc:creator>
+	<dc:contributor>Jo Smith</dc:contributor>
+	<dc:publisher>City Library</dc:publisher>
+	<dc:rights>CC BY 4.0</dc:rights>
+	<dc:language>en-GB</dc:language>
+	<dc:subject>Books</dc:subject>
+</channel>
+<item rdf:about="https://library.example/item/1">
+	<title>A Book</title>
+	<link>https://library.example/item/1</link>
+	<dc:identifier>urn:isbn:9780000000001</dc:identifier>
+	<dc:creator>An Author</dc:creator>
+	<dc:contributor>An Illustrator</dc:contributor>
+	<dc:subject>Fiction</dc:subject>
+	<dc:subject>Mystery</dc:subject>
+	<dc:rights>All rights reserved</dc:rights>
+	<dc:language>fr</dc:language>
+	<dc:date>2020-01-02T03:04:05Z</dc:date>
+</item>
+<item>
+	<dc:title>Untitled</dc:title>
+	<dc:description>Only Dublin Core</dc:description>
+	<dc:identifier>urn:isbn:9780000000002</dc:identifier>
+</item>
+</rdf:RDF>`
+
+func TestDublinCore(t *testing.T) {
+	feed, err := Parse([]byte(dublinCoreFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if feed.Publisher != "City Library" || feed.Rights != "CC BY 4.0" || feed.Language != "en-GB" {
+		t.Errorf("Expected publisher, rights and language, got %q, %q, %q", feed.Publisher, feed.Rights, feed.Language)
+	}
+	if feed.Author != "Cataloguing Team" || len(feed.Contributors) != 1 || feed.Contributors[0].Name != "Jo Smith" {
+		t.Errorf("Expected author and contributor, got %q, %v", feed.Author, feed.Contributors)
+	}
+	if !reflect.DeepEqual(feed.Categories, []string{"Books"}) {
+		t.Errorf("Expected categories from dc:subject, got %q", feed.Categories)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.ID != "urn:isbn:9780000000001" {
+		t.Errorf("Expected ID from dc:identifier, got %q", item.ID)
+	}
+	if len(item.Authors) != 1 || item.Authors[0].Name != "An Author" {
+		t.Errorf("Expected author from dc:creator, got %v", item.Authors)
+	}
+	if len(item.Contributors) != 1 || item.Contributors[0].Name != "An Illustrator" {
+		t.Errorf("Expected contributor from dc:contributor, got %v", item.Contributors)
+	}
+	if !reflect.DeepEqual(item.Categories, []string{"Fiction", "Mystery"}) {
+		t.Errorf("Expected categories from dc:subject, got %q", item.Categories)
+	}
+	if item.Rights != "All rights reserved" || item.Language != "fr" || !item.DateValid {
+		t.Errorf("Expected rights, language and date, got %q, %q, %v", item.Rights, item.Language, item.Date)
+	}
+
+	item = feed.Items[1]
+	if item.Title != "Untitled" || item.Summary != "Only Dublin Core" || item.SummaryType != ContentTypeText {
+		t.Errorf("Expected title and summary from Dublin Core, got %q, %q (%s)", item.Title, item.Summary, item.SummaryType)
+	}
+}
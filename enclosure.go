@@ -0,0 +1,60 @@
+package rss
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// mediaTypes are the MIME types of common media, by file extension. Others
+// are looked up with mime.TypeByExtension, whose answers depend on the
+// system.
+var mediaTypes = map[string]string{
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".m4b":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".wav":  "audio/wav",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".ogv":  "video/ogg",
+	".webm": "video/webm",
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+}
+
+// guessType sets the type of an enclosure that has none from the
+// extension of its URL, if it is known.
+func (e *Enclosure) guessType() {
+	if e.Type != "" {
+		return
+	}
+
+	ref, err := url.Parse(e.URL)
+	if err != nil {
+		return
+	}
+	ext := strings.ToLower(path.Ext(ref.Path))
+	if ext == "" {
+		return
+	}
+	typ, ok := mediaTypes[ext]
+	if !ok {
+		typ, _, _ = mime.ParseMediaType(mime.TypeByExtension(ext))
+	}
+	if typ != "" {
+		e.Type = typ
+		e.TypeGuessed = true
+	}
+}
@@ -0,0 +1,95 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnclosureDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     []*Enclosure
+		warnings int
+	}{{
+		name: "json feed attachment",
+		data: `{
+			"version": "https://jsonfeed.org/version/1.1",
+			"title": "Podcast",
+			"items": [{
+				"id": "1",
+				"attachments": [{
+					"url": "https://example.com/1.mp3",
+					"mime_type": "audio/mpeg",
+					"title": "Episode 1",
+					"size_in_bytes": 89970236,
+					"duration_in_seconds": 2812.5
+				}]
+			}]
+		}`,
+		want: []*Enclosure{{URL: "https://example.com/1.mp3", Type: "audio/mpeg", Title: "Episode 1", Length: 89970236, Duration: 2812500 * time.Millisecond}},
+	}, {
+		name: "json feed attachment with bad numbers",
+		data: `{
+			"version": "https://jsonfeed.org/version/1.1",
+			"title": "Podcast",
+			"items": [{
+				"id": "1",
+				"attachments": [{
+					"url": "https://example.com/1.mp3",
+					"mime_type": "audio/mpeg",
+					"size_in_bytes": -1,
+					"duration_in_seconds": -2.5
+				}]
+			}]
+		}`,
+		want:     []*Enclosure{{URL: "https://example.com/1.mp3", Type: "audio/mpeg"}},
+		warnings: 2,
+	}, {
+		name: "rss enclosure and itunes duration",
+		data: `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel>
+			<item>
+				<guid>1</guid>
+				<enclosure url="https://example.com/1.m4a?download=1" length="unknown"/>
+				<itunes:duration>1:02:03</itunes:duration>
+			</item>
+		</channel></rss>`,
+		want:     []*Enclosure{{URL: "https://example.com/1.m4a?download=1", Type: "audio/mp4", TypeGuessed: true, Duration: time.Hour + 2*time.Minute + 3*time.Second}},
+		warnings: 1,
+	}, {
+		name: "atom enclosure links",
+		data: `<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+			<id>1</id>
+			<link rel="enclosure" href="https://example.com/1.ogg" title="Episode 1" length="1000"/>
+			<link rel="related" href="https://example.com/related"/>
+		</entry></feed>`,
+		want: []*Enclosure{{URL: "https://example.com/1.ogg", Type: "audio/ogg", TypeGuessed: true, Title: "Episode 1", Length: 1000}},
+	}, {
+		name: "atom enclosure link with bad length",
+		data: `<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+			<id>1</id>
+			<link rel="enclosure" href="https://example.com/1.ogg" length="1,000"/>
+		</entry></feed>`,
+		want:     []*Enclosure{{URL: "https://example.com/1.ogg", Type: "audio/ogg", TypeGuessed: true}},
+		warnings: 1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parsing: %v", err)
+			}
+			got := feed.Items[0].Enclosures
+			if len(got) != 1 {
+				t.Fatalf("Expected 1 enclosure, got %d", len(got))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want[0], got[0])
+			}
+			if len(feed.Warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %+v", tt.warnings, feed.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package rss
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrNotReady is matched by errors.Is when Feed.Update is called before the
+// feed's Refresh time. The concrete error is a *NotReadyError.
+var ErrNotReady = errors.New("not ready to update: too soon to refresh")
+
+// ErrGone is matched by errors.Is when the server has answered 410 Gone.
+// Feed.Update returns it without fetching once a feed is gone.
+var ErrGone = errors.New("feed is gone")
+
+// ErrExpired is matched by errors.Is when a JSON Feed has declared itself
+// expired. Feed.Update returns it without fetching once a feed has expired.
+var ErrExpired = errors.New("feed has expired")
+
+// ErrUnknownFormat is returned when data is not in any supported feed format.
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// ErrLimitExceeded is matched by errors.Is when a feed exceeds its Limits.
+// The concrete error is a *LimitError.
+var ErrLimitExceeded = errors.New("feed exceeds limit")
+
+// NotReadyError is returned by Feed.Update when the feed should not be
+// checked again until Refresh.
+type NotReadyError struct {
+	Refresh time.Time
+}
+
+var _ net.Error = (*NotReadyError)(nil)
+
+func (e *NotReadyError) Error() string {
+	return fmt.Sprintf("%v: next refresh at %s", ErrNotReady, e.Refresh.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrNotReady.
+func (e *NotReadyError) Is(target error) bool {
+	return target == ErrNotReady
+}
+
+// Timeout is always false.
+func (e *NotReadyError) Timeout() bool {
+	return false
+}
+
+// Temporary is always true.
+func (e *NotReadyError) Temporary() bool {
+	return true
+}
+
+// HTTPError is returned when the server responds with a status other than
+// 2xx, or 304 to a conditional request.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+}
+
+func (e *HTTPError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return "feed server responded with " + status
+}
+
+// Is reports whether target is ErrGone and the status is 410 Gone.
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrGone && e.StatusCode == http.StatusGone
+}
+
+// LimitError is returned when a feed exceeds one of its Limits. Limit is
+// the name of the Limits field, and Max its value.
+type LimitError struct {
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s is %d", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ParseError is returned when a feed cannot be parsed. Line and Column are
+// 1-based, and zero if the position is unknown. Errors reading the feed,
+// such as a lost connection, are not ParseErrors.
+type ParseError struct {
+	Format string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("parsing %s: %v", e.Format, e.Err)
+	}
+	return fmt.Sprintf("parsing %s at line %d, column %d: %v", e.Format, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError wraps err, which occurred offset bytes into the data read
+// through lines while parsing the given format.
+func newParseError(format string, lines *lineIndex, offset int64, err error) *ParseError {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	}
+
+	out := &ParseError{Format: format, Err: err}
+	out.Line, out.Column = lines.position(offset)
+
+	// The decoder may have read past the line it reported.
+	if e, ok := err.(*xml.SyntaxError); ok && e.Line < out.Line {
+		out.Line = e.Line
+		out.Column = 0
+	}
+
+	return out
+}
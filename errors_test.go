@@ -0,0 +1,118 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<html><body>Not found</body></html>"))
+	}))
+	defer server.Close()
+
+	_, err := FetchByClient(server.URL, server.Client())
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected *HTTPError, got %#v", err)
+	}
+
+	if httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, httpErr.StatusCode)
+	}
+
+	if got := httpErr.Header.Get("Content-Type"); got != "text/html" {
+		t.Errorf("Expected headers to be kept, got Content-Type %q", got)
+	}
+}
+
+func TestNotReadyError(t *testing.T) {
+	refresh := time.Now().Add(time.Hour)
+	feed := &Feed{UpdateURL: "http://localhost/dummyrss", Refresh: refresh}
+
+	err := feed.UpdateByFunc(MakeTestdataFetchFunc("rss_2.0"))
+	if !errors.Is(err, ErrNotReady) {
+		t.Fatalf("Expected ErrNotReady, got %#v", err)
+	}
+
+	var notReady *NotReadyError
+	if !errors.As(err, &notReady) || !notReady.Refresh.Equal(refresh) {
+		t.Errorf("Expected *NotReadyError with refresh %v, got %#v", refresh, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]ParseError{
+		"test2": {Format: "rss aaaa", Line: 2, Column: 1},
+	}
+
+	for test, want := range tests {
+		name := filepath.Join("testdata", test)
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		_, err = Parse(data)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s: expected *ParseError, got %#v", name, err)
+		}
+
+		if parseErr.Format != want.Format || parseErr.Line != want.Line || parseErr.Column != want.Column {
+			t.Errorf("%s: got %s at %d:%d, want %s at %d:%d", name, parseErr.Format,
+				parseErr.Line, parseErr.Column, want.Format, want.Line, want.Column)
+		}
+	}
+}
+
+func TestReadErrorsNotParseErrors(t *testing.T) {
+	docs := map[string][2]string{
+		"rss":  {`<rss version="2.0"><channel><title>Feed</title>`, `<item><guid>%d</guid></item>`},
+		"atom": {`<feed xmlns="http://www.w3.org/2005/Atom"><title>Feed</title>`, `<entry><id>%d</id></entry>`},
+		"json": {`{"version": "https://jsonfeed.org/version/1.1", "title": "Feed", "items": [`, `{"id": "%d"},`},
+	}
+
+	errBroken := errors.New("connection reset")
+	for name, doc := range docs {
+		for _, lenient := range []bool{false, true} {
+			var data strings.Builder
+			data.WriteString(doc[0])
+			for i := 0; data.Len() < 2*sniffLen; i++ {
+				fmt.Fprintf(&data, doc[1], i)
+			}
+			r := io.MultiReader(strings.NewReader(data.String()), iotest.ErrReader(errBroken))
+
+			_, err := parse(r, "", &ParseOptions{Lenient: lenient}, nil)
+			var parseErr *ParseError
+			if !errors.Is(err, errBroken) || errors.As(err, &parseErr) {
+				t.Errorf("%s (lenient %v): expected the read error unwrapped, got %v", name, lenient, err)
+			}
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	name := filepath.Join("testdata", "test1")
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Reading %s: %v", name, err)
+	}
+
+	if _, err := Parse(data); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("%s: expected ErrUnknownFormat, got %#v", name, err)
+	}
+}
@@ -0,0 +1,134 @@
+package rss
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A DetectFunc reports whether a document is in its format, and if so which
+// version of it. prefix holds the start of the document, after any byte
+// order mark, transcoded to UTF-8.
+//
+// Detection first asks every format with an empty contentType. Only if no
+// format recognises the document itself is each asked again with the HTTP
+// Content-Type, if there is one.
+type DetectFunc func(prefix []byte, contentType string) (version string, ok bool)
+
+// A ParseFunc parses a document of its format read from r. It should fill
+// in and return p.Feed(), handing each item to p.Emit as soon as it has
+// been read.
+type ParseFunc func(r io.Reader, p *Parser) (*Feed, error)
+
+type feedFormat struct {
+	name   string
+	detect DetectFunc
+	parse  ParseFunc
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []*feedFormat // In detection order.
+)
+
+func init() {
+	RegisterFormat(FormatRSS, detectRSS, parseRSS)
+	RegisterFormat(FormatAtom, detectAtom, parseAtom)
+	RegisterFormat(FormatJSONFeed, detectJSONFeed, parseJsonFeedV1)
+}
+
+// RegisterFormat makes a feed format available to Parse and Fetch. New
+// formats are detected after those already registered. Registering a name
+// again replaces that format, keeping its place in the detection order.
+func RegisterFormat(name string, detect DetectFunc, parse ParseFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	format := &feedFormat{name: name, detect: detect, parse: parse}
+	for i, f := range formats {
+		if f.name == name {
+			formats[i] = format
+			return
+		}
+	}
+	formats = append(formats, format)
+}
+
+// Formats returns the names of the registered formats in detection order.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// SetFormatOrder changes the order in which formats are detected. The named
+// formats are tried first, in the order given, followed by any others in
+// their current order.
+func SetFormatOrder(names ...string) error {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	ordered := make([]*feedFormat, 0, len(formats))
+	used := make(map[string]bool)
+	for _, name := range names {
+		found := false
+		for _, f := range formats {
+			if f.name == name && !used[name] {
+				ordered = append(ordered, f)
+				used[name] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown or repeated format %q", name)
+		}
+	}
+	for _, f := range formats {
+		if !used[f.name] {
+			ordered = append(ordered, f)
+		}
+	}
+
+	formats = ordered
+	return nil
+}
+
+// detectFormat identifies the format of the document starting with prefix.
+// The HTTP Content-Type is only used if no format recognises the document
+// itself.
+func detectFormat(prefix []byte, contentType string) (*feedFormat, Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if version, ok := f.detect(prefix, ""); ok {
+			return f, Format{Name: f.name, Version: version}, true
+		}
+	}
+
+	if contentType != "" {
+		for _, f := range formats {
+			if version, ok := f.detect(prefix, contentType); ok {
+				return f, Format{Name: f.name, Version: version}, true
+			}
+		}
+	}
+
+	return nil, Format{}, false
+}
+
+// parseRSS parses RSS 0.90 and 1.0, which are RDF documents, and the other
+// versions of RSS.
+func parseRSS(r io.Reader, p *Parser) (*Feed, error) {
+	switch p.Format().Version {
+	case "1.0", "0.90":
+		return parseRSS1(r, p)
+	}
+	return parseRSS2(r, p)
+}
@@ -0,0 +1,104 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// detectLines and parseLines implement a toy format in which the first line
+// names the feed and every following line is an item ID.
+func detectLines(prefix []byte, contentType string) (string, bool) {
+	return "1", bytes.HasPrefix(prefix, []byte("#lines\n"))
+}
+
+func parseLines(r io.Reader, p *Parser) (*Feed, error) {
+	s := bufio.NewScanner(r)
+	s.Scan()
+	out := p.Feed()
+	out.Title = strings.TrimPrefix(s.Text(), "#lines")
+	for s.Scan() {
+		if err := p.Emit(&Item{ID: s.Text(), Title: s.Text()}); err != nil {
+			return nil, err
+		}
+	}
+	return out, s.Err()
+}
+
+func TestRegisterFormat(t *testing.T) {
+	order := Formats()
+	defer func() {
+		formatsMu.Lock()
+		formats = formats[:len(order)]
+		formatsMu.Unlock()
+	}()
+
+	RegisterFormat("lines", detectLines, parseLines)
+
+	feed, err := Parse([]byte("#lines\na\nb\na\n"))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if want := (Format{Name: "lines", Version: "1"}); feed.Format != want {
+		t.Errorf("Expected format %v, got %v", want, feed.Format)
+	}
+	if len(feed.Items) != 2 || feed.Items[0].ID != "a" || feed.Items[1].ID != "b" {
+		t.Errorf("Expected items a and b, got %v", feed.Items)
+	}
+	if feed.Refresh.IsZero() {
+		t.Error("Expected a default refresh time")
+	}
+}
+
+func TestRegisterFormatFeedRoot(t *testing.T) {
+	order := Formats()
+	defer func() {
+		formatsMu.Lock()
+		formats = formats[:len(order)]
+		formatsMu.Unlock()
+	}()
+
+	// A format with a <feed> root of its own is not taken for Atom.
+	detect := func(prefix []byte, contentType string) (string, bool) {
+		root, ok := xmlRoot(prefix)
+		return "1", ok && root.Name.Space == "urn:other" && root.Name.Local == "feed"
+	}
+	parse := func(r io.Reader, p *Parser) (*Feed, error) {
+		return p.Feed(), nil
+	}
+	RegisterFormat("other", detect, parse)
+
+	feed, err := Parse([]byte(`<feed xmlns="urn:other"/>`))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if want := (Format{Name: "other", Version: "1"}); feed.Format != want {
+		t.Errorf("Expected format %v, got %v", want, feed.Format)
+	}
+}
+
+func TestSetFormatOrder(t *testing.T) {
+	order := Formats()
+	defer SetFormatOrder(order...)
+
+	if err := SetFormatOrder(FormatJSONFeed); err != nil {
+		t.Fatalf("SetFormatOrder: %v", err)
+	}
+	want := []string{FormatJSONFeed, FormatRSS, FormatAtom}
+	if got := Formats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+
+	if err := SetFormatOrder("unknown"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if err := SetFormatOrder(FormatRSS, FormatRSS); err == nil {
+		t.Error("Expected an error for a repeated format")
+	}
+	if got := Formats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a failed call to keep order %v, got %v", want, got)
+	}
+}
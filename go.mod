@@ -1,5 +1,5 @@
 module github.com/jrupac/rss
 
-go 1.17
+go 1.21
 
 require github.com/axgle/mahonia v0.0.0-20180208002826-3358181d7394
@@ -0,0 +1,221 @@
+package rss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ITunesFeed is the podcast metadata of a feed, as given by Apple's iTunes
+// namespace. See https://podcasters.apple.com/support/823-podcast-requirements.
+type ITunesFeed struct {
+	Author     string            `json:"author"`
+	Summary    string            `json:"summary"`
+	Image      string            `json:"image"` // URL of the artwork.
+	Categories []*ITunesCategory `json:"categories"`
+	Explicit   bool              `json:"explicit"`
+	Type       string            `json:"type"` // "episodic" or "serial".
+	Owner      *Person           `json:"owner"`
+	Block      bool              `json:"block"`      // The podcast should not be listed.
+	Complete   bool              `json:"complete"`   // No more episodes will be published.
+	NewFeedURL string            `json:"newfeedurl"` // The podcast has moved to this URL.
+}
+
+// ITunesCategory is an iTunes category, with the subcategories given
+// inside it.
+type ITunesCategory struct {
+	Name          string   `json:"name"`
+	Subcategories []string `json:"subcategories"`
+}
+
+// ITunesItem is the metadata of a podcast episode, as given by Apple's
+// iTunes namespace.
+type ITunesItem struct {
+	Title       string        `json:"title"`
+	Author      string        `json:"author"`
+	Summary     string        `json:"summary"`
+	Image       string        `json:"image"` // URL of the artwork.
+	Duration    time.Duration `json:"duration"`
+	Explicit    bool          `json:"explicit"`
+	Episode     int           `json:"episode"`
+	Season      int           `json:"season"`
+	EpisodeType string        `json:"episodetype"` // "full", "trailer" or "bonus".
+	Block       bool          `json:"block"`       // The episode should not be listed.
+}
+
+// fill fills in the fields of feed left empty by RSS, and adds the
+// author and categories.
+func (f *ITunesFeed) fill(feed *Feed) {
+	feed.Authors = appendPeople(feed.Authors, parsePerson(f.Author))
+	if feed.Author == "" {
+		feed.Author = f.Author
+	}
+	if feed.Description == "" {
+		feed.Description = f.Summary
+	}
+	if feed.Image.Href == "" {
+		feed.Image.Href = f.Image
+	}
+	for _, category := range f.Categories {
+		feed.Categories = append(feed.Categories, category.Name)
+	}
+}
+
+// fill is like ITunesFeed.fill, for an item.
+func (i *ITunesItem) fill(item *Item) {
+	if item.Title == "" {
+		item.Title = i.Title
+	}
+	item.Authors = appendPeople(item.Authors, parsePerson(i.Author))
+	if item.Summary == "" && i.Summary != "" {
+		item.Summary = i.Summary
+		item.SummaryType = ContentTypeText
+	}
+	if item.Image.Href == "" {
+		item.Image.Href = i.Image
+	}
+	// The duration is that of the episode, which is the first enclosure.
+	if len(item.Enclosures) > 0 && item.Enclosures[0].Duration == 0 && item.Enclosures[0].Medium != "image" {
+		item.Enclosures[0].Duration = i.Duration
+	}
+}
+
+// itunesChannel holds the iTunes elements of a channel.
+type itunesChannel struct {
+	ITunesAuthor     string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ITunesSummary    string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesImage      itunesImage      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ITunesCategories []itunesCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+	ITunesExplicit   string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	ITunesType       string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd type"`
+	ITunesOwner      itunesOwner      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd owner"`
+	ITunesBlock      string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block"`
+	ITunesComplete   string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd complete"`
+	ITunesNewFeedURL string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd new-feed-url"`
+}
+
+// itunesItem holds the iTunes elements of an item.
+type itunesItem struct {
+	ITunesTitle       string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesAuthor      string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ITunesSummary     string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesImage       itunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ITunesDuration    string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesExplicit    string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	ITunesEpisode     string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesSeason      string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesEpisodeType string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episodeType"`
+	ITunesBlock       string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd block"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text          string           `xml:"text,attr"`
+	Subcategories []itunesCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd name"`
+	Email string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd email"`
+}
+
+// feed returns the channel's podcast metadata, or nil if it has none.
+func (c *itunesChannel) feed() *ITunesFeed {
+	text := c.ITunesAuthor + c.ITunesSummary + c.ITunesImage.Href + c.ITunesExplicit +
+		c.ITunesType + c.ITunesBlock + c.ITunesComplete + c.ITunesNewFeedURL
+	if text == "" && len(c.ITunesCategories) == 0 && c.ITunesOwner == (itunesOwner{}) {
+		return nil
+	}
+
+	out := &ITunesFeed{
+		Author:     strings.TrimSpace(c.ITunesAuthor),
+		Summary:    strings.TrimSpace(c.ITunesSummary),
+		Image:      strings.TrimSpace(c.ITunesImage.Href),
+		Explicit:   itunesYes(c.ITunesExplicit, "true", "explicit"),
+		Type:       strings.ToLower(strings.TrimSpace(c.ITunesType)),
+		Block:      itunesYes(c.ITunesBlock),
+		Complete:   itunesYes(c.ITunesComplete),
+		NewFeedURL: strings.TrimSpace(c.ITunesNewFeedURL),
+	}
+	for _, category := range c.ITunesCategories {
+		next := &ITunesCategory{Name: strings.TrimSpace(category.Text)}
+		for _, sub := range category.Subcategories {
+			next.Subcategories = append(next.Subcategories, strings.TrimSpace(sub.Text))
+		}
+		out.Categories = append(out.Categories, next)
+	}
+	if c.ITunesOwner != (itunesOwner{}) {
+		out.Owner = &Person{Name: strings.TrimSpace(c.ITunesOwner.Name), Email: strings.TrimSpace(c.ITunesOwner.Email)}
+	}
+	return out
+}
+
+// item returns the item's podcast metadata, or nil if it has none.
+// Problems with the values are reported through p.
+func (i *itunesItem) item(p *Parser, id string) *ITunesItem {
+	if *i == (itunesItem{}) {
+		return nil
+	}
+
+	out := &ITunesItem{
+		Title:       strings.TrimSpace(i.ITunesTitle),
+		Author:      strings.TrimSpace(i.ITunesAuthor),
+		Summary:     strings.TrimSpace(i.ITunesSummary),
+		Image:       strings.TrimSpace(i.ITunesImage.Href),
+		Explicit:    itunesYes(i.ITunesExplicit, "true", "explicit"),
+		EpisodeType: strings.ToLower(strings.TrimSpace(i.ITunesEpisodeType)),
+		Block:       itunesYes(i.ITunesBlock),
+	}
+	if i.ITunesDuration != "" {
+		d, err := parseDuration(i.ITunesDuration)
+		if err != nil {
+			p.warn(p.items, id, WarnInvalidValue, "duration", err.Error())
+		}
+		out.Duration = d
+	}
+	out.Episode = p.parseInt(p.items, id, "episode", i.ITunesEpisode)
+	out.Season = p.parseInt(p.items, id, "season", i.ITunesSeason)
+	return out
+}
+
+// itunesYes reports whether s is "yes", or one of the other values given.
+func itunesYes(s string, yes ...string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "yes" {
+		return true
+	}
+	for _, y := range yes {
+		if s == y {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDuration parses a duration given in seconds, or as HH:MM:SS or
+// MM:SS, as in itunes:duration. The seconds may have a fraction.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		digits := strings.Replace(part, ".", "", 1)
+		if !isDigits(digits, "0123456789") || (i < len(parts)-1 && digits != part) {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,124 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const itunesFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+	<title>A Podcast</title>
+	<link>https://podcast.example/</link>
+	<itunes:author>The Hosts</itunes:author>
+	<itunes:summary>Talking about things.</itunes:summary>
+	<itunes:image href="https://podcast.example/art.jpg"/>
+	<itunes:category text="Society &amp; Culture">
+		<itunes:category text="History"/>
+	</itunes:category>
+	<itunes:category text="Comedy"/>
+	<itunes:explicit>false</itunes:explicit>
+	<itunes:type>Serial</itunes:type>
+	<itunes:owner>
+		<itunes:name>Owner</itunes:name>
+		<itunes:email>owner@podcast.example</itunes:email>
+	</itunes:owner>
+	<itunes:complete>Yes</itunes:complete>
+	<itunes:new-feed-url>https://new.podcast.example/feed</itunes:new-feed-url>
+	<item>
+		<guid>1</guid>
+		<itunes:title>Episode One</itunes:title>
+		<itunes:duration>1:02:03</itunes:duration>
+		<itunes:explicit>yes</itunes:explicit>
+		<itunes:episode>1</itunes:episode>
+		<itunes:season>2</itunes:season>
+		<itunes:episodeType>Trailer</itunes:episodeType>
+		<itunes:image href="ep1.jpg"/>
+	</item>
+	<item>
+		<guid>2</guid>
+		<title>Plain</title>
+	</item>
+	<item>
+		<guid>3</guid>
+		<itunes:duration>soon</itunes:duration>
+		<itunes:episode>three</itunes:episode>
+	</item>
+</channel>
+</rss>`
+
+func TestITunes(t *testing.T) {
+	feed, err := Parse([]byte(itunesFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	want := &ITunesFeed{
+		Author:  "The Hosts",
+		Summary: "Talking about things.",
+		Image:   "https://podcast.example/art.jpg",
+		Categories: []*ITunesCategory{
+			{Name: "Society & Culture", Subcategories: []string{"History"}},
+			{Name: "Comedy"},
+		},
+		Type:       "serial",
+		Owner:      &Person{Name: "Owner", Email: "owner@podcast.example"},
+		Complete:   true,
+		NewFeedURL: "https://new.podcast.example/feed",
+	}
+	if !reflect.DeepEqual(feed.ITunes, want) {
+		t.Errorf("Expected %+v, got %+v", want, feed.ITunes)
+	}
+	if feed.Author != "The Hosts" || feed.Description != "Talking about things." {
+		t.Errorf("Expected author and description from iTunes, got %q, %q", feed.Author, feed.Description)
+	}
+	if !reflect.DeepEqual(feed.Categories, []string{"Society & Culture", "Comedy"}) {
+		t.Errorf("Expected iTunes categories, got %q", feed.Categories)
+	}
+
+	item := feed.Items[0]
+	wantItem := &ITunesItem{
+		Title:       "Episode One",
+		Image:       "https://podcast.example/ep1.jpg",
+		Duration:    time.Hour + 2*time.Minute + 3*time.Second,
+		Explicit:    true,
+		Episode:     1,
+		Season:      2,
+		EpisodeType: "trailer",
+	}
+	if !reflect.DeepEqual(item.ITunes, wantItem) {
+		t.Errorf("Expected %+v, got %+v", wantItem, item.ITunes)
+	}
+	if item.Title != "Episode One" {
+		t.Errorf("Expected title from iTunes, got %q", item.Title)
+	}
+	if feed.Items[1].ITunes != nil {
+		t.Errorf("Expected no iTunes metadata, got %+v", feed.Items[1].ITunes)
+	}
+
+	if len(feed.Warnings) != 2 || feed.Warnings[0].Code != WarnInvalidValue || feed.Warnings[0].Item != 2 {
+		t.Errorf("Expected two invalid-value warnings for item 2, got %v", feed.Warnings)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"3723":     3723 * time.Second,
+		"62:03":    62*time.Minute + 3*time.Second,
+		"1:02:03":  time.Hour + 2*time.Minute + 3*time.Second,
+		"01:02:03": time.Hour + 2*time.Minute + 3*time.Second,
+		" 90.5 ":   90*time.Second + 500*time.Millisecond,
+	}
+	for in, want := range tests {
+		if got, err := parseDuration(in); err != nil || got != want {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "soon", "1:2:3:4", "1.5:00", "-5", "1e3"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q): expected error", in)
+		}
+	}
+}
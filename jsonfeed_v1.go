@@ -1,98 +1,152 @@
 package rss
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"time"
+	"io"
+	"strings"
 )
 
-func parseJsonFeedV1(data []byte) (*Feed, error) {
-	warnings := false
+func parseJsonFeedV1(r io.Reader, p *Parser) (*Feed, error) {
+	var err error
 	feed := json_v1Feed{}
-	p := json.NewDecoder(bytes.NewReader(data))
-	err := p.Decode(&feed)
+	seen := make(map[string]bool)
+	d := json.NewDecoder(r)
+
+	// Items are resolved against the URL of the feed and inherit its
+	// authors, which are normally given before the items. An item that
+	// comes before the fields it needs is held until the whole feed has
+	// been read, along with those that follow it, to keep them in order.
+	// So all the items are held when the feed gives no feed_url, or no
+	// authors and the items give none of their own, and MaxItems is not
+	// applied to them until then.
+	var pending []json_v1Item
+	emit := func(item *json_v1Item) error {
+		p.itemBase = feed.FeedURL
+		next := feed.item(p, item)
+		if next == nil {
+			return nil
+		}
+		return p.Emit(next)
+	}
+	err = decodeJSONFeed(d, &feed, seen, func(d *json.Decoder) error {
+		item := json_v1Item{}
+		if err := d.Decode(&item); err != nil {
+			return err
+		}
+		ready := seen["feed_url"] && (seen["author"] || seen["authors"] || len(item.people()) > 0)
+		if len(pending) > 0 || !ready {
+			pending = append(pending, item)
+			return nil
+		}
+		return emit(&item)
+	})
+	for i := 0; err == nil && i < len(pending); i++ {
+		err = emit(&pending[i])
+	}
 	if err != nil {
-		return nil, err
+		return nil, p.decodeError(d.InputOffset(), err)
 	}
 
-	out := new(Feed)
+	out := p.out
 	out.Title = feed.Title
 	out.Description = feed.Description
+	out.Language = strings.TrimSpace(feed.Language)
 	out.Link = feed.HomePageURL
 	out.UpdateURL = feed.FeedURL
-	out.Image = &Image{URL: feed.Favicon}
-	out.Refresh = time.Now().Add(10 * time.Minute)
+	out.SelfURL = feed.FeedURL
+	out.NextURL = feed.NextURL
+	for _, hub := range feed.Hubs {
+		out.Hubs = append(out.Hubs, &Hub{Type: strings.TrimSpace(hub.Type), URL: strings.TrimSpace(hub.URL)})
+	}
+	// The icon is large, as for a podcast, and the favicon small.
+	out.Image = &Image{URL: feed.Icon}
+	if out.Image.URL == "" {
+		out.Image.URL = feed.Favicon
+	}
+	out.Favicon = feed.Favicon
+	out.Authors = feed.people()
+	out.Author = authorName(out.Authors)
+	out.Expired = feed.Expired
 
-	out.Items = make([]*Item, 0, len(feed.Items))
-	out.ItemMap = make(map[string]struct{})
+	return out, nil
+}
 
-	// Process items.
-	for _, item := range feed.Items {
+// decodeJSONFeed decodes the top-level object read by d into feed, except
+// that the elements of its "items" array are decoded one at a time by
+// item, so that they need not all be held in memory. Each other field is
+// decoded into feed as soon as it is read, and its key is recorded in
+// seen in lower case, so that items can use those that come before them.
+func decodeJSONFeed(d *json.Decoder, feed interface{}, seen map[string]bool, item func(d *json.Decoder) error) error {
+	if err := expectDelim(d, '{'); err != nil {
+		return err
+	}
 
-		// Skip items already known.
-		if _, ok := out.ItemMap[item.ID]; ok {
-			continue
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return err
 		}
+		key := tok.(string)
 
-		next := new(Item)
-		next.Title = item.Title
-		next.Summary = item.Summary
-		if item.ContentHTML != "" {
-			next.Content = item.ContentHTML
-		} else {
-			next.Content = item.ContentText
+		if !strings.EqualFold(key, "items") {
+			var value json.RawMessage
+			if err := d.Decode(&value); err != nil {
+				return err
+			}
+			if err := decodeJSONField(key, value, feed); err != nil {
+				return err
+			}
+			seen[strings.ToLower(key)] = true
+			continue
 		}
 
-		date := item.DateModified
-		if date == "" {
-			date = item.DatePublished
+		tok, err = d.Token()
+		if err != nil {
+			return err
 		}
-		if date != "" {
-			next.Date, err = parseTime(date)
-			if err == nil {
-				next.DateValid = true
-			}
+		if tok == nil {
+			continue
 		}
-		next.ID = item.ID
-		next.Link = item.URL
-		for _, attachment := range item.Attachments {
-			next.Enclosures = append(next.Enclosures, &Enclosure{
-				URL:    attachment.URL,
-				Type:   attachment.MIMEType,
-				Length: attachment.DurationInSeconds,
-			})
+		if tok != json.Delim('[') {
+			return fmt.Errorf("expected array of items, got %v", tok)
 		}
-		next.Read = false
-
-		if next.ID == "" {
-			if debug {
-				fmt.Printf("[w] Item %q has no ID and will be ignored.\n", next.Title)
-				fmt.Printf("[w] %#v\n", item)
+		for d.More() {
+			if err := item(d); err != nil {
+				return err
 			}
-			warnings = true
-			continue
 		}
-
-		if _, ok := out.ItemMap[next.ID]; ok {
-			if debug {
-				fmt.Printf("[w] Item %q has duplicate ID.\n", next.Title)
-				fmt.Printf("[w] %#v\n", next)
-			}
-			warnings = true
-			continue
+		if err := expectDelim(d, ']'); err != nil {
+			return err
 		}
-
-		out.Items = append(out.Items, next)
-		out.ItemMap[next.ID] = struct{}{}
-		out.Unread++
 	}
 
-	if warnings && debug {
-		fmt.Printf("[i] Encountered warnings:\n%s\n", data)
+	return expectDelim(d, '}')
+}
+
+// decodeJSONField decodes a single field of an object into v.
+func decodeJSONField(key string, value json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(map[string]json.RawMessage{key: value})
+	if err != nil {
+		return err
 	}
+	err = json.Unmarshal(data, v)
+	if e, ok := err.(*json.UnmarshalTypeError); ok {
+		// The offset is into data, not the original document.
+		e.Offset = -1
+	}
+	return err
+}
 
-	return out, nil
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
 }
 
 type json_v1Item struct {
@@ -108,41 +162,122 @@ type json_v1Item struct {
 	DatePublished string              `json:"date_published"`
 	DateModified  string              `json:"date_modified"`
 	Author        json_v1Author       `json:"author"`
+	Authors       []json_v1Author     `json:"authors"`
 	Tags          []string            `json:"tags"`
+	Language      string              `json:"language"`
 	Attachments   []json_v1Attachment `json:"attachments"`
 }
 
+// item converts an item of the feed. Items with no ID are dropped, and nil
+// is returned for them.
+func (f *json_v1Feed) item(p *Parser, item *json_v1Item) *Item {
+	next := new(Item)
+	next.Title = item.Title
+	next.Summary = item.Summary
+	next.SummaryType = contentType(next.Summary, ContentTypeText)
+	if item.ContentHTML != "" {
+		next.Content = item.ContentHTML
+		next.ContentType = ContentTypeHTML
+	} else {
+		next.Content = item.ContentText
+		next.ContentType = contentType(next.Content, ContentTypeText)
+	}
+
+	p.itemDate(next, "date_modified", item.DateModified)
+	p.itemDate(next, "date_published", item.DatePublished)
+	next.ID = item.ID
+	next.Link = item.URL
+	if item.ExternalURL != "" {
+		// The item is about a page elsewhere, as in a linkblog.
+		next.Links = append(next.Links, &Link{Href: item.ExternalURL, Rel: "related"})
+	}
+	if item.Image != "" {
+		next.Image = &Image{URL: item.Image}
+	}
+	if item.BannerImage != "" {
+		next.Banner = &Image{URL: item.BannerImage}
+	}
+	next.Categories = item.Tags
+	next.Language = strings.TrimSpace(item.Language)
+	next.Authors = item.people()
+	if len(next.Authors) == 0 {
+		// Items inherit the authors of the feed.
+		next.Authors = f.people()
+	}
+	for _, attachment := range item.Attachments {
+		next.Enclosures = append(next.Enclosures, &Enclosure{
+			URL:      attachment.URL,
+			Type:     attachment.MIMEType,
+			Title:    attachment.Title,
+			Length:   uint(p.parseFloat(p.items, item.ID, "size_in_bytes", attachment.SizeInBytes.String())),
+			Duration: seconds(p.parseFloat(p.items, item.ID, "duration_in_seconds", attachment.DurationInSeconds.String())),
+		})
+	}
+	next.Read = false
+
+	if next.ID == "" {
+		p.Drop(WarnMissingID, "id", fmt.Sprintf("item %q has no ID", next.Title))
+		return nil
+	}
+	return next
+}
+
 type json_v1Author struct {
 	Name   string `json:"name"`
 	URL    string `json:"url"`
 	Avatar string `json:"avatar"`
 }
 
+// people returns the authors of a feed or item. JSON Feed 1.1 replaced
+// the single author with a list of authors.
+func people(authors []json_v1Author, author json_v1Author) []*Person {
+	var out []*Person
+	for _, a := range authors {
+		out = appendPeople(out, a.person())
+	}
+	return appendPeople(out, author.person())
+}
+
+func (a *json_v1Author) person() *Person {
+	return &Person{Name: strings.TrimSpace(a.Name), URI: strings.TrimSpace(a.URL), Image: strings.TrimSpace(a.Avatar)}
+}
+
+func (f *json_v1Feed) people() []*Person {
+	return people(f.Authors, f.Author)
+}
+
+func (i *json_v1Item) people() []*Person {
+	return people(i.Authors, i.Author)
+}
+
 type json_v1Hub struct {
 	Type string `json:"type"`
 	URL  string `json:"url"`
 }
 
+// Numbers are parsed leniently, like those of XML feeds, so that negative
+// ones are reported rather than wrapped around.
 type json_v1Attachment struct {
-	URL               string `json:"url"`
-	MIMEType          string `json:"mime_type"`
-	Title             string `json:"title"`
-	SizeInBytes       uint   `json:"size_in_bytes"`
-	DurationInSeconds uint   `json:"duration_in_seconds"`
+	URL               string      `json:"url"`
+	MIMEType          string      `json:"mime_type"`
+	Title             string      `json:"title"`
+	SizeInBytes       json.Number `json:"size_in_bytes"`
+	DurationInSeconds json.Number `json:"duration_in_seconds"` // May have a fraction.
 }
 
 type json_v1Feed struct {
-	Version     string        `json:"version"`
-	Title       string        `json:"title"`
-	HomePageURL string        `json:"home_page_url"`
-	FeedURL     string        `json:"feed_url"`
-	Description string        `json:"Description"`
-	UserComment string        `json:"user_comment"`
-	NextURL     string        `json:"next_url"`
-	Icon        string        `json:"icon"`
-	Favicon     string        `json:"favicon"`
-	Author      json_v1Author `json:"author"`
-	Expired     bool          `json:"expired"`
-	Hubs        []json_v1Hub  `json:"hubs"`
-	Items       []json_v1Item `json:"items"`
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	HomePageURL string          `json:"home_page_url"`
+	FeedURL     string          `json:"feed_url"`
+	Description string          `json:"description"`
+	UserComment string          `json:"user_comment"`
+	NextURL     string          `json:"next_url"`
+	Icon        string          `json:"icon"`
+	Favicon     string          `json:"favicon"`
+	Author      json_v1Author   `json:"author"`
+	Authors     []json_v1Author `json:"authors"`
+	Language    string          `json:"language"`
+	Expired     bool            `json:"expired"`
+	Hubs        []json_v1Hub    `json:"hubs"`
 }
@@ -1,9 +1,15 @@
 package rss
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestParseJSONFeedV1(t *testing.T) {
@@ -38,3 +44,160 @@ func TestParseJSONFeedV1(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONFeedV1_1(t *testing.T) {
+	const data = `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "A Blog",
+		"description": "Things I found.",
+		"home_page_url": "https://blog.example/",
+		"feed_url": "https://blog.example/feed.json",
+		"next_url": "https://blog.example/feed.json?page=2",
+		"icon": "https://blog.example/icon.png",
+		"favicon": "https://blog.example/favicon.ico",
+		"language": "en-GB",
+		"authors": [{"name": "A Writer", "url": "https://writer.example/", "avatar": "https://writer.example/me.jpg"}],
+		"hubs": [{"type": "WebSub", "url": "https://hub.example/"}],
+		"items": [{
+			"id": "1",
+			"url": "https://blog.example/1",
+			"external_url": "https://elsewhere.example/story",
+			"content_text": "A link.",
+			"image": "1.jpg",
+			"banner_image": "1-banner.jpg",
+			"tags": ["links", "news"],
+			"language": "fr"
+		}, {
+			"id": "2",
+			"content_text": "An old post.",
+			"author": {"name": "A Guest"}
+		}]
+	}`
+
+	feed, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	writer := &Person{Name: "A Writer", URI: "https://writer.example/", Image: "https://writer.example/me.jpg"}
+	if feed.Description != "Things I found." || feed.Language != "en-GB" {
+		t.Errorf("Expected description and language, got %q and %q", feed.Description, feed.Language)
+	}
+	if feed.NextURL != "https://blog.example/feed.json?page=2" {
+		t.Errorf("Expected next URL, got %q", feed.NextURL)
+	}
+	if feed.Image == nil || feed.Image.URL != "https://blog.example/icon.png" {
+		t.Errorf("Expected the icon as the image, got %+v", feed.Image)
+	}
+	if feed.Favicon != "https://blog.example/favicon.ico" {
+		t.Errorf("Expected favicon, got %q", feed.Favicon)
+	}
+	if want := []*Hub{{Type: "WebSub", URL: "https://hub.example/"}}; !reflect.DeepEqual(feed.Hubs, want) {
+		t.Errorf("Expected hubs %+v, got %+v", want, feed.Hubs)
+	}
+	if want := []*Person{writer}; !reflect.DeepEqual(feed.Authors, want) {
+		t.Errorf("Expected authors %+v, got %+v", want, feed.Authors)
+	}
+	if feed.Expired {
+		t.Error("Expected the feed not to be expired")
+	}
+
+	item := feed.Items[0]
+	if want := []*Link{{Href: "https://elsewhere.example/story", Rel: "related"}}; !reflect.DeepEqual(item.Links, want) {
+		t.Errorf("Expected links %+v, got %+v", want, item.Links)
+	}
+	if item.Image == nil || item.Image.URL != "https://blog.example/1.jpg" {
+		t.Errorf("Expected image, got %+v", item.Image)
+	}
+	if item.Banner == nil || item.Banner.URL != "https://blog.example/1-banner.jpg" {
+		t.Errorf("Expected banner, got %+v", item.Banner)
+	}
+	if want := []string{"links", "news"}; !reflect.DeepEqual(item.Categories, want) {
+		t.Errorf("Expected categories %q, got %q", want, item.Categories)
+	}
+	if item.Language != "fr" {
+		t.Errorf("Expected language %q, got %q", "fr", item.Language)
+	}
+	if want := []*Person{writer}; !reflect.DeepEqual(item.Authors, want) {
+		t.Errorf("Expected inherited authors %+v, got %+v", want, item.Authors)
+	}
+
+	// JSON Feed 1.0 gave a single author.
+	if want := []*Person{{Name: "A Guest"}}; !reflect.DeepEqual(feed.Items[1].Authors, want) {
+		t.Errorf("Expected authors %+v, got %+v", want, feed.Items[1].Authors)
+	}
+}
+
+func TestJSONFeedV1ItemsFirst(t *testing.T) {
+	const data = `{
+		"items": [{"id": "1", "url": "posts/1", "content_text": "A post."}],
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "A Blog",
+		"feed_url": "https://blog.example/feed.json",
+		"authors": [{"name": "A Writer"}],
+		"favicon": "https://blog.example/favicon.ico",
+		"expired": true
+	}`
+
+	feed, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	// Items see the fields that follow them.
+	if len(feed.Items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.Link != "https://blog.example/posts/1" {
+		t.Errorf("Expected the link resolved against the feed URL, got %q", item.Link)
+	}
+	if want := []*Person{{Name: "A Writer"}}; !reflect.DeepEqual(item.Authors, want) {
+		t.Errorf("Expected inherited authors %+v, got %+v", want, item.Authors)
+	}
+	if !feed.Expired {
+		t.Error("Expected the feed to be expired")
+	}
+
+	// With no icon, the favicon is the image too.
+	if feed.Favicon != "https://blog.example/favicon.ico" {
+		t.Errorf("Expected favicon, got %q", feed.Favicon)
+	}
+	if feed.Image == nil || feed.Image.URL != "https://blog.example/favicon.ico" {
+		t.Errorf("Expected the favicon as the image, got %+v", feed.Image)
+	}
+}
+
+func TestJSONFeedV1StreamsItems(t *testing.T) {
+	var data strings.Builder
+	data.WriteString(`{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "A Blog",
+		"feed_url": "https://blog.example/feed.json",
+		"authors": [{"name": "A Writer"}],
+		"items": [`)
+	for i := 0; data.Len() < 4*sniffLen; i++ {
+		fmt.Fprintf(&data, `{"id": "%d", "content_text": "Post %d."},`, i, i)
+	}
+
+	// The stream breaks before the end of the items.
+	errBroken := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader(data.String()), iotest.ErrReader(errBroken))
+
+	var ids []string
+	_, err := ParseItems(r, func(item *Item) error {
+		ids = append(ids, item.ID)
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Expected an error from the broken stream")
+	}
+	if len(ids) == 0 {
+		t.Fatal("Expected items to be passed on before the error")
+	}
+	for i, id := range ids {
+		if want := fmt.Sprint(i); id != want {
+			t.Fatalf("Expected item %q, got %q", want, id)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kinds of Repair.
+const (
+	RepairLeadingJunk     = "leading-junk"     // Data before the XML was skipped.
+	RepairHTMLEntity      = "html-entity"      // An HTML entity such as &nbsp; was decoded.
+	RepairUnknownEntity   = "unknown-entity"   // An unknown entity was kept as text.
+	RepairBareAmpersand   = "bare-ampersand"   // An unescaped & was kept as text.
+	RepairUnclosedElement = "unclosed-element" // An element left open was closed.
+)
+
+// A Repair describes a problem in a feed's XML that was worked around by
+// lenient parsing. Each distinct problem is reported once, with the line
+// it was first seen on and the number of times it was seen.
+type Repair struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"` // Such as the entity or element, if any.
+	Line   int    `json:"line"`
+	Count  int    `json:"count"`
+}
+
+func (r Repair) String() string {
+	if r.Detail == "" {
+		return fmt.Sprintf("line %d: %s (%d times)", r.Line, r.Kind, r.Count)
+	}
+	return fmt.Sprintf("line %d: %s %s (%d times)", r.Line, r.Kind, r.Detail, r.Count)
+}
+
+// lenientAutoClose lists the elements closed by lenient parsing when they
+// are left open. It is xml.HTMLAutoClose without <link>, which RSS uses
+// with text content.
+var lenientAutoClose = func() []string {
+	var names []string
+	for _, name := range xml.HTMLAutoClose {
+		if name != "link" {
+			names = append(names, name)
+		}
+	}
+	return names
+}()
+
+// xmlEntities are the entities predefined by XML.
+var xmlEntities = map[string]bool{"amp": true, "lt": true, "gt": true, "quot": true, "apos": true}
+
+// skipJunk discards anything before the XML declaration in the data
+// buffered by br, such as whitespace or error messages printed by the
+// server. Data that already starts a feed is left alone, so a declaration
+// quoted inside a feed is not mistaken for its start. It returns the
+// number of bytes and lines skipped.
+func skipJunk(br *bufio.Reader) (n, lines int) {
+	prefix, _ := br.Peek(sniffLen)
+	n = bytes.Index(prefix, []byte("<?xml"))
+	if n <= 0 {
+		return 0, 0
+	}
+	if _, _, ok := detectFormat(prefix[:n], ""); ok {
+		return 0, 0
+	}
+	lines = bytes.Count(prefix[:n], []byte("\n"))
+	br.Discard(n)
+	return n, lines
+}
+
+// repairLog collects the repairs made while parsing a feed.
+type repairLog struct {
+	repairs []Repair
+	index   map[Repair]int // Kind and Detail of each repair to its index.
+}
+
+func (l *repairLog) add(kind, detail string, line int) {
+	key := Repair{Kind: kind, Detail: detail}
+	if i, ok := l.index[key]; ok {
+		l.repairs[i].Count++
+		return
+	}
+	if l.index == nil {
+		l.index = make(map[Repair]int)
+	}
+	l.index[key] = len(l.repairs)
+	l.repairs = append(l.repairs, Repair{Kind: kind, Detail: detail, Line: line, Count: 1})
+}
+
+// Scanner states.
+const (
+	scanText = iota
+	scanRef
+	scanCDATA
+	scanComment
+)
+
+// entityScanner passes XML through unchanged, recording the entity
+// references and ampersands that a strict decoder would reject.
+type entityScanner struct {
+	r       io.ByteReader
+	log     *repairLog
+	line    int
+	state   int
+	ref     []byte // Read since the '&' that started a reference.
+	refLine int
+	tail    [9]byte // The last bytes read, to find CDATA and comments.
+}
+
+// newEntityScanner returns a scanner reading from r, which starts on the
+// given line of the document.
+func newEntityScanner(r io.Reader, log *repairLog, line int) *entityScanner {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &entityScanner{r: br, log: log, line: line}
+}
+
+func (s *entityScanner) Read(b []byte) (int, error) {
+	for i := range b {
+		c, err := s.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		b[i] = c
+	}
+	return len(b), nil
+}
+
+func (s *entityScanner) ReadByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err != nil {
+		if s.state == scanRef {
+			s.log.add(RepairBareAmpersand, "", s.refLine)
+			s.state = scanText
+		}
+		return c, err
+	}
+	s.scan(c)
+	return c, nil
+}
+
+func (s *entityScanner) scan(c byte) {
+	if c == '\n' {
+		s.line++
+	}
+	copy(s.tail[:], s.tail[1:])
+	s.tail[len(s.tail)-1] = c
+
+	switch s.state {
+	case scanRef:
+		if isRefByte(c) && len(s.ref) < 32 {
+			s.ref = append(s.ref, c)
+			return
+		}
+		s.state = scanText
+		if c == ';' {
+			s.reference(string(s.ref))
+			return
+		}
+		s.log.add(RepairBareAmpersand, "", s.refLine)
+		// c may itself start a reference or markup.
+		s.scanText(c)
+	case scanCDATA:
+		if bytes.HasSuffix(s.tail[:], []byte("]]>")) {
+			s.state = scanText
+		}
+	case scanComment:
+		if bytes.HasSuffix(s.tail[:], []byte("-->")) {
+			s.state = scanText
+		}
+	default:
+		s.scanText(c)
+	}
+}
+
+func (s *entityScanner) scanText(c byte) {
+	switch {
+	case c == '&':
+		s.state = scanRef
+		s.ref = s.ref[:0]
+		s.refLine = s.line
+	case bytes.HasSuffix(s.tail[:], []byte("<![CDATA[")):
+		s.state = scanCDATA
+	case bytes.HasSuffix(s.tail[:], []byte("<!--")):
+		s.state = scanComment
+	}
+}
+
+// reference records a repair if &name; is not valid XML.
+func (s *entityScanner) reference(name string) {
+	switch {
+	case xmlEntities[name]:
+	case strings.HasPrefix(name, "#x"):
+		if !isDigits(name[2:], "0123456789abcdefABCDEF") {
+			s.log.add(RepairBareAmpersand, "", s.refLine)
+		}
+	case strings.HasPrefix(name, "#"):
+		if !isDigits(name[1:], "0123456789") {
+			s.log.add(RepairBareAmpersand, "", s.refLine)
+		}
+	case xml.HTMLEntity[name] != "":
+		s.log.add(RepairHTMLEntity, "&"+name+";", s.refLine)
+	case name == "":
+		s.log.add(RepairBareAmpersand, "", s.refLine)
+	default:
+		s.log.add(RepairUnknownEntity, "&"+name+";", s.refLine)
+	}
+}
+
+func isRefByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '#'
+}
+
+func isDigits(s, digits string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(digits, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// closeTracker records the elements that a non-strict decoder closes
+// because they were left open. Such an end element is followed by a token
+// that was read before it, so the decoder's offset does not move.
+type closeTracker struct {
+	d      *xml.Decoder
+	s      *entityScanner
+	log    *repairLog
+	offset int64
+	prev   *xml.EndElement
+}
+
+func (c *closeTracker) Token() (xml.Token, error) {
+	tok, err := c.d.Token()
+	if err != nil {
+		return tok, err
+	}
+
+	offset := c.d.InputOffset()
+	if offset == c.offset && c.prev != nil {
+		c.log.add(RepairUnclosedElement, "<"+c.prev.Name.Local+">", c.s.line)
+	}
+	c.offset = offset
+
+	c.prev = nil
+	if end, ok := tok.(xml.EndElement); ok {
+		c.prev = &end
+	}
+	return tok, nil
+}
@@ -0,0 +1,137 @@
+package rss
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const brokenFeed = `<b>Warning</b>: Undefined variable $x in feed.php on line 3<br />
+  <?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+<title>Fish & Chips</title>
+<item>
+<guid>1</guid>
+<title>Caf&eacute; &mdash; menu&nbsp;update</title>
+<description>First line<br>Second line &copy2024 &bogus;</description>
+</item>
+<item>
+<guid>2</guid>
+<title><![CDATA[Salt & vinegar]]></title>
+<description>One<br>Two<hr>Three</description>
+</item>
+</channel>
+</rss>`
+
+func TestParseLenient(t *testing.T) {
+	if _, err := Parse([]byte(brokenFeed)); err == nil {
+		t.Fatal("Expected strict parsing to fail")
+	}
+
+	feed, err := ParseWithOptions([]byte(brokenFeed), &ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if feed.Title != "Fish & Chips" {
+		t.Errorf("Expected title %q, got %q", "Fish & Chips", feed.Title)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+	}
+	if want := "Café — menu update"; feed.Items[0].Title != want {
+		t.Errorf("Expected item title %q, got %q", want, feed.Items[0].Title)
+	}
+	if want := "Salt & vinegar"; feed.Items[1].Title != want {
+		t.Errorf("Expected item title %q, got %q", want, feed.Items[1].Title)
+	}
+	// Markup left in a description is kept, not reduced to its text.
+	if want := "One<br/>Two<hr/>Three"; feed.Items[1].Summary != want {
+		t.Errorf("Expected item summary %q, got %q", want, feed.Items[1].Summary)
+	}
+
+	want := []Repair{
+		{Kind: RepairLeadingJunk, Detail: "68 bytes", Line: 1, Count: 1},
+		{Kind: RepairBareAmpersand, Line: 5, Count: 2},
+		{Kind: RepairHTMLEntity, Detail: "&eacute;", Line: 8, Count: 1},
+		{Kind: RepairHTMLEntity, Detail: "&mdash;", Line: 8, Count: 1},
+		{Kind: RepairHTMLEntity, Detail: "&nbsp;", Line: 8, Count: 1},
+		{Kind: RepairUnknownEntity, Detail: "&bogus;", Line: 9, Count: 1},
+		{Kind: RepairUnclosedElement, Detail: "<br>", Line: 9, Count: 2},
+		{Kind: RepairUnclosedElement, Detail: "<hr>", Line: 14, Count: 1},
+	}
+	if !reflect.DeepEqual(feed.Repairs, want) {
+		t.Errorf("Expected repairs:\n%v\ngot:\n%v", want, feed.Repairs)
+	}
+}
+
+func TestParseLenientWellFormed(t *testing.T) {
+	for _, name := range []string{"rss_0.92", "rss_1.0", "rss_1.0_enclosure", "rss_2.0", "rss_2.0_content_encoded", "atom_1.0"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		strict, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", name, err)
+		}
+		lenient, err := ParseWithOptions(data, &ParseOptions{Lenient: true})
+		if err != nil {
+			t.Fatalf("Parsing %s leniently: %v", name, err)
+		}
+
+		if len(lenient.Repairs) != 0 {
+			t.Errorf("%s: expected no repairs, got %v", name, lenient.Repairs)
+		}
+		if len(lenient.Items) != len(strict.Items) {
+			t.Errorf("%s: expected %d items, got %d", name, len(strict.Items), len(lenient.Items))
+			continue
+		}
+		for i := range strict.Items {
+			if lenient.Items[i].Summary != strict.Items[i].Summary || lenient.Items[i].Content != strict.Items[i].Content {
+				t.Errorf("%s: item %d differs when parsed leniently", name, i)
+			}
+		}
+	}
+}
+
+func TestParseLenientHTML(t *testing.T) {
+	const data = `<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel>
+<item>
+<guid>1</guid>
+<description>line&lt;br&gt;two &amp;amp; more</description>
+<content:encoded><![CDATA[<p>line<br>two</p>]]></content:encoded>
+</item>
+<item>
+<guid>2</guid>
+<description>line<br/>two <b>bold</b></description>
+</item>
+</channel></rss>`
+
+	strict, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	lenient, err := ParseWithOptions([]byte(data), &ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Parsing leniently: %v", err)
+	}
+
+	want := []struct{ summary, content string }{
+		{"line<br>two &amp; more", "<p>line<br>two</p>"},
+		{"line<br/>two <b>bold</b>", ""},
+	}
+	for i, w := range want {
+		for mode, feed := range map[string]*Feed{"strict": strict, "lenient": lenient} {
+			if got := feed.Items[i].Summary; got != w.summary {
+				t.Errorf("%s: expected item %d summary %q, got %q", mode, i, w.summary, got)
+			}
+			if got := feed.Items[i].Content; got != w.content {
+				t.Errorf("%s: expected item %d content %q, got %q", mode, i, w.content, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package rss
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Limits bounds the resources that fetching and parsing a single feed may
+// use. A feed that exceeds any of them is rejected with a *LimitError.
+// Zero fields impose no limit.
+type Limits struct {
+	MaxBytes         int64 // Bytes read from the response body or reader.
+	MaxItems         int   // Items kept in a feed.
+	MaxContentLength int   // Bytes in an item's title, summary or content.
+	MaxDepth         int   // Nesting depth of XML elements.
+}
+
+// DefaultLimits are the Limits applied when
+// fetching and parsing feeds.
+//
+// They are unset by default. Set them when
+// reading feeds from untrusted sources, so a
+// single hostile feed cannot exhaust memory.
+var DefaultLimits Limits
+
+// limitReader returns a reader that fails with a *LimitError once more
+// than max bytes have been read from r. If max is zero, it returns r.
+func limitReader(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, max: max, remaining: max + 1}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64 // One more than may be read, to detect overruns.
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if int64(len(b)) > l.remaining {
+		b = b[:l.remaining]
+	}
+	n, err := l.r.Read(b)
+	l.remaining -= int64(n)
+	if l.remaining <= 0 {
+		return n, &LimitError{Limit: "MaxBytes", Max: l.max}
+	}
+	return n, err
+}
+
+// depthLimiter fails with a *LimitError once elements are nested more than
+// max deep.
+type depthLimiter struct {
+	t     xml.TokenReader
+	max   int
+	depth int
+}
+
+func (l *depthLimiter) Token() (xml.Token, error) {
+	tok, err := l.t.Token()
+	switch tok.(type) {
+	case xml.StartElement:
+		l.depth++
+		if l.depth > l.max {
+			return nil, &LimitError{Limit: "MaxDepth", Max: int64(l.max)}
+		}
+	case xml.EndElement:
+		l.depth--
+	}
+	return tok, err
+}
+
+// checkItem returns a *LimitError if item does not fit within l, given
+// that count items have already been kept.
+func (l Limits) checkItem(item *Item, count int) error {
+	if l.MaxItems > 0 && count >= l.MaxItems {
+		return &LimitError{Limit: "MaxItems", Max: int64(l.MaxItems)}
+	}
+	if l.MaxContentLength > 0 {
+		for _, field := range []string{item.Title, item.Summary, item.Content} {
+			if len(field) > l.MaxContentLength {
+				return &LimitError{Limit: "MaxContentLength", Max: int64(l.MaxContentLength)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package rss
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		testdata string
+		limits   Limits
+		limit    string
+	}{
+		{"rss_1.0", Limits{MaxBytes: 1024}, "MaxBytes"},
+		{"rss_1.0", Limits{MaxBytes: 64}, "MaxBytes"},
+		{"rss_1.0", Limits{MaxItems: 10}, "MaxItems"},
+		{"jsonfeed_v1", Limits{MaxContentLength: 100}, "MaxContentLength"},
+		{"atom_1.0", Limits{MaxDepth: 2}, "MaxDepth"},
+		{"rss_2.0", Limits{MaxBytes: 1 << 20, MaxItems: 2, MaxContentLength: 1024, MaxDepth: 4}, ""},
+	}
+
+	defer func(limits Limits) { DefaultLimits = limits }(DefaultLimits)
+
+	for _, tt := range tests {
+		name := filepath.Join("testdata", tt.testdata)
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		DefaultLimits = tt.limits
+		_, err = Parse(data)
+
+		if tt.limit == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error with %+v: %v", name, tt.limits, err)
+			}
+			continue
+		}
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%s: expected *LimitError with %+v, got %#v", name, tt.limits, err)
+		} else if limitErr.Limit != tt.limit {
+			t.Errorf("%s: got limit %s, want %s", name, limitErr.Limit, tt.limit)
+		}
+	}
+}
+
+func TestFetchMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat(" ", 1<<20)))
+	}))
+	defer server.Close()
+
+	defer func(limits Limits) { DefaultLimits = limits }(DefaultLimits)
+	DefaultLimits = Limits{MaxBytes: 1 << 10}
+
+	if _, err := FetchByClient(server.URL, server.Client()); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected ErrLimitExceeded, got %v", err)
+	}
+}
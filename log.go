@@ -0,0 +1,25 @@
+package rss
+
+import (
+	"context"
+	"log/slog"
+)
+
+// DefaultLogger receives the diagnostics of
+// parsing and fetching feeds that are not
+// given a logger in their options. Messages
+// are logged with the feed's URL and format,
+// and the item concerned, as attributes.
+//
+// If it is nil, diagnostics are discarded.
+var DefaultLogger *slog.Logger
+
+// discardLogger is used when no logger is set.
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,67 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// logRecords decodes the records written by a slog.JSONHandler.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	d := json.NewDecoder(buf)
+	for d.More() {
+		var record map[string]interface{}
+		if err := d.Decode(&record); err != nil {
+			t.Fatalf("Decoding log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestParseLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := ParseWithOptions([]byte(warningFeed), &ParseOptions{Logger: logger}); err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 log records, got %d: %v", len(records), records)
+	}
+	if records[0]["level"] != "DEBUG" || records[0]["format"] != "rss 2.0" {
+		t.Errorf("Expected a debug record for the format, got %v", records[0])
+	}
+	dup := records[3]
+	if dup["level"] != "WARN" || dup["code"] != WarnDuplicateID || dup["item_id"] != "1" || dup["item"] != 2.0 {
+		t.Errorf("Expected a warning for the duplicate ID, got %v", dup)
+	}
+}
+
+func TestFetchLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	old := DefaultLogger
+	DefaultLogger = slog.New(slog.NewJSONHandler(buf, nil))
+	defer func() { DefaultLogger = old }()
+
+	feed := &Feed{UpdateURL: server.URL, RequestFunc: server.Client().Do}
+	if err := feed.Update(); err == nil {
+		t.Fatal("Expected an error for a gone feed")
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 1 || records[0]["msg"] != "feed is gone" || records[0]["url"] != server.URL {
+		t.Errorf("Expected one record for the gone feed, got %v", records)
+	}
+}
@@ -0,0 +1,337 @@
+package rss
+
+import (
+	"strings"
+)
+
+// Media is the metadata of an item's media, as given by Media RSS. The
+// media themselves are among the item's enclosures. See
+// https://www.rssboard.org/media-rss.
+type Media struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Keywords    []string       `json:"keywords"`
+	Credits     []*MediaCredit `json:"credits"`
+	Ratings     []*MediaRating `json:"ratings"`
+	Player      *MediaPlayer   `json:"player"`
+	Thumbnails  []*Image       `json:"thumbnails"`
+}
+
+// MediaCredit is someone who contributed to the media.
+type MediaCredit struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"` // Such as "producer" or "actor".
+	Scheme string `json:"scheme"`
+}
+
+// MediaRating is the suitability of the media for an audience, such as
+// "adult" in the "urn:simple" scheme.
+type MediaRating struct {
+	Value  string `json:"value"`
+	Scheme string `json:"scheme"`
+}
+
+// MediaPlayer is a web page in which the media can be played.
+type MediaPlayer struct {
+	URL    string `json:"url"`
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+}
+
+// mrssElements holds the Media RSS elements of an item.
+type mrssElements struct {
+	MediaGroups   []mrssGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaContents []mrssContent `xml:"http://search.yahoo.com/mrss/ content"`
+	mrssMetadata
+}
+
+// mrssMetadata holds the elements that describe media, which may be given
+// for an item, a group or a single media object.
+type mrssMetadata struct {
+	MediaTitle       string          `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string          `xml:"http://search.yahoo.com/mrss/ description"`
+	MediaKeywords    string          `xml:"http://search.yahoo.com/mrss/ keywords"`
+	MediaThumbnails  []mrssThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaCredits     []mrssCredit    `xml:"http://search.yahoo.com/mrss/ credit"`
+	MediaRatings     []mrssRating    `xml:"http://search.yahoo.com/mrss/ rating"`
+	MediaPlayer      *mrssPlayer     `xml:"http://search.yahoo.com/mrss/ player"`
+}
+
+// See https://www.rssboard.org/media-rss#media-group for details.
+type mrssGroup struct {
+	Contents []mrssContent `xml:"http://search.yahoo.com/mrss/ content"`
+	mrssMetadata
+}
+
+// See https://www.rssboard.org/media-rss#media-content for details.
+// Numbers are parsed leniently, as feeds often give fractions.
+type mrssContent struct {
+	URL          string `xml:"url,attr"`
+	FileSize     string `xml:"fileSize,attr"`
+	Type         string `xml:"type,attr"`
+	Medium       string `xml:"medium,attr"`
+	IsDefault    string `xml:"isDefault,attr"`
+	Expression   string `xml:"expression,attr"`
+	Bitrate      string `xml:"bitrate,attr"`
+	Framerate    string `xml:"framerate,attr"`
+	SamplingRate string `xml:"samplingrate,attr"`
+	Channels     string `xml:"channels,attr"`
+	Duration     string `xml:"duration,attr"`
+	Height       string `xml:"height,attr"`
+	Width        string `xml:"width,attr"`
+	Lang         string `xml:"lang,attr"`
+	mrssMetadata
+}
+
+func (r *mrssContent) ToEnclosure(p *Parser, id string) *Enclosure {
+	out := new(Enclosure)
+	out.URL = strings.TrimSpace(r.URL)
+	out.Type = strings.TrimSpace(r.Type)
+	out.Title = strings.TrimSpace(r.MediaTitle)
+	out.Length = uint(p.parseFloat(p.items, id, "fileSize", r.FileSize))
+	out.Medium = strings.ToLower(strings.TrimSpace(r.Medium))
+	out.IsDefault = strings.EqualFold(strings.TrimSpace(r.IsDefault), "true")
+	out.Bitrate = uint(p.parseFloat(p.items, id, "bitrate", r.Bitrate))
+	out.Width = uint(p.parseFloat(p.items, id, "width", r.Width))
+	out.Height = uint(p.parseFloat(p.items, id, "height", r.Height))
+	out.Duration = seconds(p.parseFloat(p.items, id, "duration", r.Duration))
+	return out
+}
+
+// See https://www.rssboard.org/media-rss#media-thumbnails for details.
+// Dimensions are parsed leniently, like those of mrssContent.
+type mrssThumbnail struct {
+	URL    string `xml:"url,attr"`
+	Height string `xml:"height,attr"`
+	Width  string `xml:"width,attr"`
+	Time   string `xml:"time,attr"`
+}
+
+func (r *mrssThumbnail) Image(p *Parser, id string) *Image {
+	out := new(Image)
+	out.URL = strings.TrimSpace(r.URL)
+	out.Width = uint32(p.parseFloat(p.items, id, "width", r.Width))
+	out.Height = uint32(p.parseFloat(p.items, id, "height", r.Height))
+	return out
+}
+
+// thumbnailEnclosure returns a thumbnail as an enclosure. Its type is
+// guessed when the item is emitted, as thumbnails do not give one.
+func thumbnailEnclosure(thumbnail *Image) *Enclosure {
+	out := new(Enclosure)
+	out.URL = thumbnail.URL
+	out.Medium = "image"
+	out.Width = uint(thumbnail.Width)
+	out.Height = uint(thumbnail.Height)
+	return out
+}
+
+// See https://www.rssboard.org/media-rss#media-credit for details.
+type mrssCredit struct {
+	Role     string `xml:"role,attr"`
+	Scheme   string `xml:"scheme,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+// See https://www.rssboard.org/media-rss#media-rating for details.
+type mrssRating struct {
+	Scheme   string `xml:"scheme,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+// See https://www.rssboard.org/media-rss#media-player for details.
+// Dimensions are parsed leniently, like those of mrssContent.
+type mrssPlayer struct {
+	URL    string `xml:"url,attr"`
+	Height string `xml:"height,attr"`
+	Width  string `xml:"width,attr"`
+}
+
+func (r *mrssPlayer) Player(p *Parser, id string) *MediaPlayer {
+	out := new(MediaPlayer)
+	out.URL = strings.TrimSpace(r.URL)
+	out.Width = uint(p.parseFloat(p.items, id, "width", r.Width))
+	out.Height = uint(p.parseFloat(p.items, id, "height", r.Height))
+	return out
+}
+
+// enclosures adds the item's media objects to enclosures, followed by
+// the thumbnails of media, the item's metadata as returned by m.media. A
+// media object that repeats an enclosure fills in what the enclosure
+// leaves out instead.
+func (m *mrssElements) enclosures(p *Parser, id string, enclosures []*Enclosure, media *Media) []*Enclosure {
+	contents := m.MediaContents
+	for _, group := range m.MediaGroups {
+		contents = append(contents, group.Contents...)
+	}
+
+	for i := range contents {
+		enclosures = addEnclosure(enclosures, contents[i].ToEnclosure(p, id))
+	}
+	if media != nil {
+		for _, thumbnail := range media.Thumbnails {
+			enclosures = addEnclosure(enclosures, thumbnailEnclosure(thumbnail))
+		}
+	}
+	return enclosures
+}
+
+// addEnclosure adds e to enclosures, unless one has the same URL, in which
+// case e fills in its empty fields.
+func addEnclosure(enclosures []*Enclosure, e *Enclosure) []*Enclosure {
+	if e.URL == "" {
+		return enclosures
+	}
+	for _, old := range enclosures {
+		if old.URL != e.URL {
+			continue
+		}
+		if old.Type == "" {
+			old.Type = e.Type
+		}
+		if old.Title == "" {
+			old.Title = e.Title
+		}
+		if old.Length == 0 {
+			old.Length = e.Length
+		}
+		if old.Medium == "" {
+			old.Medium = e.Medium
+		}
+		old.IsDefault = old.IsDefault || e.IsDefault
+		if old.Bitrate == 0 {
+			old.Bitrate = e.Bitrate
+		}
+		if old.Width == 0 && old.Height == 0 {
+			old.Width, old.Height = e.Width, e.Height
+		}
+		if old.Duration == 0 {
+			old.Duration = e.Duration
+		}
+		return enclosures
+	}
+	return append(enclosures, e)
+}
+
+// media returns the metadata of the item's media, or nil if there is none.
+// Metadata given for the item is preferred to that given for a group, and
+// that of the first group to the others. Problems with the values are
+// reported through p.
+func (m *mrssElements) media(p *Parser, id string) *Media {
+	all := []*mrssMetadata{&m.mrssMetadata}
+	for i := range m.MediaGroups {
+		all = append(all, &m.MediaGroups[i].mrssMetadata)
+	}
+
+	out := new(Media)
+	for _, md := range all {
+		if out.Title == "" {
+			out.Title = strings.TrimSpace(md.MediaTitle)
+		}
+		if out.Description == "" {
+			out.Description = strings.TrimSpace(md.MediaDescription)
+		}
+		if out.Keywords == nil {
+			for _, keyword := range strings.Split(md.MediaKeywords, ",") {
+				if keyword = strings.TrimSpace(keyword); keyword != "" {
+					out.Keywords = append(out.Keywords, keyword)
+				}
+			}
+		}
+		for _, credit := range md.MediaCredits {
+			out.Credits = append(out.Credits, &MediaCredit{
+				Name:   strings.TrimSpace(credit.Chardata),
+				Role:   strings.ToLower(strings.TrimSpace(credit.Role)),
+				Scheme: strings.TrimSpace(credit.Scheme),
+			})
+		}
+		for _, rating := range md.MediaRatings {
+			scheme := strings.TrimSpace(rating.Scheme)
+			if scheme == "" {
+				scheme = "urn:simple"
+			}
+			out.Ratings = append(out.Ratings, &MediaRating{Value: strings.TrimSpace(rating.Chardata), Scheme: scheme})
+		}
+		if out.Player == nil && md.MediaPlayer != nil {
+			out.Player = md.MediaPlayer.Player(p, id)
+		}
+		for i := range md.MediaThumbnails {
+			if strings.TrimSpace(md.MediaThumbnails[i].URL) != "" {
+				out.Thumbnails = append(out.Thumbnails, md.MediaThumbnails[i].Image(p, id))
+			}
+		}
+	}
+
+	if out.Title == "" && out.Description == "" && out.Keywords == nil && out.Credits == nil &&
+		out.Ratings == nil && out.Player == nil && out.Thumbnails == nil {
+		return nil
+	}
+	return out
+}
+
+// resolve resolves the media's links against base.
+func (m *Media) resolve(base string) {
+	if m.Player != nil {
+		m.Player.URL = resolveURL(base, m.Player.URL)
+	}
+	for _, thumbnail := range m.Thumbnails {
+		thumbnail.URL = resolveURL(base, thumbnail.URL)
+	}
+}
+
+// RenditionConstraints limit the enclosures chosen by Item.BestRendition.
+// Zero fields do not limit the choice, except that images, such as
+// thumbnails, are only chosen when Medium or Type asks for them.
+type RenditionConstraints struct {
+	Medium     string // Such as "video". Enclosures of unknown medium match any.
+	Type       string // A MIME type, or a prefix such as "video/".
+	MaxBitrate uint   // In kilobits per second.
+	MaxWidth   uint
+	MaxHeight  uint
+}
+
+func (c *RenditionConstraints) allow(e *Enclosure) bool {
+	images := strings.EqualFold(c.Medium, "image") || strings.HasPrefix(strings.ToLower(c.Type), "image/")
+	switch {
+	case strings.EqualFold(e.Medium, "image") && !images:
+		return false
+	case c.Medium != "" && e.Medium != "" && !strings.EqualFold(c.Medium, e.Medium):
+		return false
+	case c.Type != "" && !strings.HasPrefix(strings.ToLower(e.Type), strings.ToLower(c.Type)):
+		return false
+	case c.MaxBitrate > 0 && e.Bitrate > c.MaxBitrate:
+		return false
+	case c.MaxWidth > 0 && e.Width > c.MaxWidth:
+		return false
+	case c.MaxHeight > 0 && e.Height > c.MaxHeight:
+		return false
+	}
+	return true
+}
+
+// BestRendition returns the enclosure of the highest resolution, and then
+// bitrate, that meets c, or nil if none does. Between equals, the default
+// rendition given by Media RSS is preferred, and then the first.
+func (i *Item) BestRendition(c RenditionConstraints) *Enclosure {
+	var best *Enclosure
+	for _, e := range i.Enclosures {
+		if !c.allow(e) {
+			continue
+		}
+		if best == nil || betterRendition(e, best) {
+			best = e
+		}
+	}
+	return best
+}
+
+// betterRendition reports whether a is of higher quality than b.
+func betterRendition(a, b *Enclosure) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if a.Bitrate != b.Bitrate {
+		return a.Bitrate > b.Bitrate
+	}
+	return a.IsDefault && !b.IsDefault
+}
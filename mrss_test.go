@@ -0,0 +1,215 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const mrssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+	<title>Videos</title>
+	<link>https://video.example/</link>
+	<item>
+		<guid>1</guid>
+		<title>A video</title>
+		<enclosure url="https://video.example/1-720.mp4" type="video/mp4" length="5000"/>
+		<media:title>A video, in full</media:title>
+		<media:keywords>cats, dogs , </media:keywords>
+		<media:group>
+			<media:content url="https://video.example/1-360.mp4" type="video/mp4" medium="video" bitrate="500" width="640" height="360" duration="61.5"/>
+			<media:content url="https://video.example/1-720.mp4" type="video/mp4" medium="video" bitrate="1500" width="1280" height="720" duration="61.5" isDefault="true"/>
+			<media:content url="https://video.example/1-1080.mp4" type="video/mp4" medium="video" bitrate="4000" width="1920" height="1080" duration="61.5"/>
+			<media:content url="https://video.example/1.m4a" type="audio/mp4" medium="audio" bitrate="128" fileSize="1e3"/>
+			<media:description>Shown first in the group.</media:description>
+			<media:thumbnail url="thumb.jpg" width="320" height="180"/>
+			<media:credit role="Producer" scheme="urn:ebu">A Producer</media:credit>
+			<media:rating>nonadult</media:rating>
+			<media:player url="/player/1" width="640" height="360"/>
+		</media:group>
+	</item>
+	<item>
+		<guid>2</guid>
+		<title>A picture</title>
+		<media:content url="https://video.example/2.png" type="image/png" medium="image" width="two"/>
+	</item>
+</channel>
+</rss>`
+
+func TestMediaRSS(t *testing.T) {
+	feed, err := Parse([]byte(mrssFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	duration := 61500 * time.Millisecond
+	want := []*Enclosure{
+		{URL: "https://video.example/1-720.mp4", Type: "video/mp4", Length: 5000, Medium: "video", IsDefault: true, Bitrate: 1500, Width: 1280, Height: 720, Duration: duration},
+		{URL: "https://video.example/1-360.mp4", Type: "video/mp4", Medium: "video", Bitrate: 500, Width: 640, Height: 360, Duration: duration},
+		{URL: "https://video.example/1-1080.mp4", Type: "video/mp4", Medium: "video", Bitrate: 4000, Width: 1920, Height: 1080, Duration: duration},
+		{URL: "https://video.example/1.m4a", Type: "audio/mp4", Length: 1000, Medium: "audio", Bitrate: 128},
+		{URL: "https://video.example/thumb.jpg", Type: "image/jpeg", TypeGuessed: true, Medium: "image", Width: 320, Height: 180},
+	}
+	if got := feed.Items[0].Enclosures; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected enclosures %+v, got %+v", want, got)
+	}
+
+	wantMedia := &Media{
+		Title:       "A video, in full",
+		Description: "Shown first in the group.",
+		Keywords:    []string{"cats", "dogs"},
+		Credits:     []*MediaCredit{{Name: "A Producer", Role: "producer", Scheme: "urn:ebu"}},
+		Ratings:     []*MediaRating{{Value: "nonadult", Scheme: "urn:simple"}},
+		Player:      &MediaPlayer{URL: "https://video.example/player/1", Width: 640, Height: 360},
+		Thumbnails:  []*Image{{URL: "https://video.example/thumb.jpg", Width: 320, Height: 180}},
+	}
+	if got := feed.Items[0].Media; !reflect.DeepEqual(got, wantMedia) {
+		t.Errorf("Expected media %+v, got %+v", wantMedia, got)
+	}
+	if got := feed.Items[0].Image; got == nil || got.URL != "https://video.example/thumb.jpg" {
+		t.Errorf("Expected the thumbnail as the image, got %+v", got)
+	}
+
+	// Content outside a group is read too, and bad numbers are left out.
+	wantPicture := []*Enclosure{{URL: "https://video.example/2.png", Type: "image/png", Medium: "image"}}
+	if got := feed.Items[1].Enclosures; !reflect.DeepEqual(got, wantPicture) {
+		t.Errorf("Expected enclosures %+v, got %+v", wantPicture, got)
+	}
+	if feed.Items[1].Media != nil {
+		t.Errorf("Expected no media metadata, got %+v", feed.Items[1].Media)
+	}
+	if len(feed.Warnings) != 1 || feed.Warnings[0].Code != WarnInvalidValue || feed.Warnings[0].Element != "width" {
+		t.Errorf("Expected a warning about the width, got %+v", feed.Warnings)
+	}
+}
+
+func TestMediaRSSLenientDimensions(t *testing.T) {
+	const data = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+	<title>Videos</title>
+	<item>
+		<guid>1</guid>
+		<media:player url="https://video.example/player/1" width="100%" height="360.0"/>
+		<media:thumbnail url="https://video.example/thumb.jpg" width="120.0" height="auto"/>
+	</item>
+</channel>
+</rss>`
+
+	feed, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	item := feed.Items[0]
+	wantMedia := &Media{
+		Player:     &MediaPlayer{URL: "https://video.example/player/1", Height: 360},
+		Thumbnails: []*Image{{URL: "https://video.example/thumb.jpg", Width: 120}},
+	}
+	if !reflect.DeepEqual(item.Media, wantMedia) {
+		t.Errorf("Expected media %+v, got %+v", wantMedia, item.Media)
+	}
+	if len(item.Enclosures) != 1 || item.Enclosures[0].Width != 120 {
+		t.Errorf("Expected the thumbnail as an enclosure, got %+v", item.Enclosures)
+	}
+
+	// Each bad value is reported once, by the attribute's name.
+	var elements []string
+	for _, w := range feed.Warnings {
+		if w.Code == WarnInvalidValue {
+			elements = append(elements, w.Element)
+		}
+	}
+	if want := []string{"width", "height"}; !reflect.DeepEqual(elements, want) {
+		t.Errorf("Expected warnings about %v, got %+v", want, feed.Warnings)
+	}
+}
+
+func TestMediaRSSInAtom(t *testing.T) {
+	const data = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+	<title>Channel</title>
+	<entry>
+		<id>yt:video:1</id>
+		<title>A video</title>
+		<link rel="alternate" href="https://www.youtube.com/watch?v=1"/>
+		<media:group>
+			<media:title>A video</media:title>
+			<media:content url="https://www.youtube.com/v/1" type="application/x-shockwave-flash" width="640" height="390"/>
+			<media:thumbnail url="https://i.ytimg.com/vi/1/hqdefault.jpg" width="480" height="360"/>
+			<media:description>About the video.</media:description>
+		</media:group>
+	</entry>
+</feed>`
+
+	feed, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	item := feed.Items[0]
+	if len(item.Enclosures) != 2 || item.Enclosures[0].URL != "https://www.youtube.com/v/1" || item.Enclosures[0].Width != 640 {
+		t.Errorf("Expected the media content as an enclosure, got %+v", item.Enclosures)
+	}
+	if item.Media == nil || item.Media.Title != "A video" || item.Media.Description != "About the video." {
+		t.Errorf("Expected media metadata, got %+v", item.Media)
+	}
+	if item.Image == nil || item.Image.URL != "https://i.ytimg.com/vi/1/hqdefault.jpg" {
+		t.Errorf("Expected the thumbnail as the image, got %+v", item.Image)
+	}
+}
+
+func TestBestRendition(t *testing.T) {
+	feed, err := Parse([]byte(mrssFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	item := feed.Items[0]
+
+	tests := []struct {
+		name        string
+		constraints RenditionConstraints
+		want        string
+	}{
+		{"any", RenditionConstraints{}, "https://video.example/1-1080.mp4"},
+		{"video", RenditionConstraints{Medium: "video"}, "https://video.example/1-1080.mp4"},
+		{"max height", RenditionConstraints{Type: "video/", MaxHeight: 720}, "https://video.example/1-720.mp4"},
+		{"max bitrate", RenditionConstraints{Medium: "video", MaxBitrate: 1000}, "https://video.example/1-360.mp4"},
+		{"audio", RenditionConstraints{Medium: "audio"}, "https://video.example/1.m4a"},
+		{"none", RenditionConstraints{Medium: "video", MaxWidth: 100}, ""},
+		{"image", RenditionConstraints{Medium: "image"}, "https://video.example/thumb.jpg"},
+		{"image type", RenditionConstraints{Type: "image/"}, "https://video.example/thumb.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if e := item.BestRendition(tt.constraints); e != nil {
+				got = e.URL
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBestRenditionSkipsThumbnails(t *testing.T) {
+	const data = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+	<title>Podcast</title>
+	<item>
+		<guid>1</guid>
+		<enclosure url="https://audio.example/1.mp3" type="audio/mpeg" length="1000"/>
+		<media:thumbnail url="https://audio.example/1.jpg" width="100" height="100"/>
+	</item>
+</channel>
+</rss>`
+
+	feed, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if e := feed.Items[0].BestRendition(RenditionConstraints{}); e == nil || e.URL != "https://audio.example/1.mp3" {
+		t.Errorf("Expected the audio enclosure, got %+v", e)
+	}
+}
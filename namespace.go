@@ -0,0 +1,109 @@
+package rss
+
+import "encoding/xml"
+
+// Namespaces of the extensions read from RSS feeds.
+const (
+	nsContent = "http://purl.org/rss/1.0/modules/content/"
+	nsDC      = "http://purl.org/dc/elements/1.1/"
+	nsMedia   = "http://search.yahoo.com/mrss/"
+	nsEnc     = "http://purl.oclc.org/net/rss_2.0/enc#"
+	nsITunes  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	nsSy      = "http://purl.org/rss/1.0/modules/syndication/"
+	nsPodcast = "https://podcastindex.org/namespace/1.0"
+)
+
+// nsRSS and nsAtomAny are the namespaces given to the elements of RSS and
+// Atom themselves while they are decoded. RSS 2.0 elements have no
+// namespace, which a struct tag cannot ask for: a tag without a namespace
+// matches the element in any namespace, so <atom:link> would be taken for
+// <link>. Atom's namespace differs between versions. The names cannot be
+// mistaken for prefixes, which have no colon.
+const (
+	nsRSS     = "urn:rss"
+	nsAtomAny = "urn:atom"
+)
+
+// Namespaces that some RSS 2.0 feeds declare as their default, though
+// RSS 2.0 has none.
+const (
+	nsRSS20Userland = "http://backend.userland.com/rss2"
+	nsRSS20Harvard  = "http://blogs.law.harvard.edu/tech/rss"
+)
+
+// rssSpaces and atomSpaces are the namespaces of the elements of each
+// version of RSS and Atom.
+var (
+	rssSpaces  = []string{"", nsRSS10, nsRSS090, nsRSS20Userland, nsRSS20Harvard}
+	atomSpaces = []string{"", nsAtom, nsAtom03}
+)
+
+// wellKnownPrefixes maps the prefixes commonly used for namespaces to the
+// namespaces, for feeds that use a prefix without declaring it.
+var wellKnownPrefixes = map[string]string{
+	"atom":    nsAtom,
+	"content": nsContent,
+	"dc":      nsDC,
+	"enc":     nsEnc,
+	"itunes":  nsITunes,
+	"media":   nsMedia,
+	"podcast": nsPodcast,
+	"rdf":     nsRDF,
+	"sy":      nsSy,
+}
+
+// namespaceAliases maps other names that feeds use for a namespace to the
+// name it is read by.
+var namespaceAliases = map[string]string{
+	"https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md": nsPodcast,
+}
+
+// namespaceReader puts the elements in any of the namespaces in own into
+// space, the elements with an undeclared but well-known prefix into its
+// namespace, and those in an alias of a namespace into the namespace. The
+// namespace of a root <rss> element is taken to be RSS's own, whatever it
+// is.
+type namespaceReader struct {
+	t     xml.TokenReader
+	space string
+	own   map[string]bool
+	root  bool // Whether the root element has been read.
+}
+
+func newNamespaceReader(t xml.TokenReader, space string, own []string) *namespaceReader {
+	n := &namespaceReader{t: t, space: space, own: make(map[string]bool)}
+	for _, space := range own {
+		n.own[space] = true
+	}
+	return n
+}
+
+func (n *namespaceReader) Token() (xml.Token, error) {
+	tok, err := n.t.Token()
+	switch t := tok.(type) {
+	case xml.StartElement:
+		if !n.root {
+			n.root = true
+			if n.space == nsRSS && t.Name.Local == "rss" {
+				n.own[t.Name.Space] = true
+			}
+		}
+		t.Name = n.name(t.Name)
+		tok = t
+	case xml.EndElement:
+		t.Name = n.name(t.Name)
+		tok = t
+	}
+	return tok, err
+}
+
+func (n *namespaceReader) name(name xml.Name) xml.Name {
+	if n.own[name.Space] {
+		name.Space = n.space
+	} else if space, ok := wellKnownPrefixes[name.Space]; ok {
+		name.Space = space
+	} else if space, ok := namespaceAliases[name.Space]; ok {
+		name.Space = space
+	}
+	return name
+}
@@ -0,0 +1,84 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+const mixedNamespaceFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:ev="http://purl.org/rss/1.0/modules/event/" xmlns:x="http://example.com/x">
+<channel>
+<atom:link rel="self" type="application/rss+xml" href="https://example.com/feed.xml"/>
+<atom:link rel="hub" href="https://hub.example/"/>
+<link>https://example.com/</link>
+<title>Mixed</title>
+<x:title>Not the title</x:title>
+<item>
+	<guid>1</guid>
+	<title>First</title>
+	<link>https://example.com/1</link>
+	<atom:link rel="replies" type="text/html" href="https://example.com/1#comments"/>
+	<pubDate>Sun, 04 Mar 2018 09:30:00 GMT</pubDate>
+	<ev:startdate>2020-01-01T00:00:00Z</ev:startdate>
+	<x:date>2020-01-01T00:00:00Z</x:date>
+	<x:encoded>Not the content</x:encoded>
+	<dc:creator>Undeclared Prefix</dc:creator>
+</item>
+</channel>
+</rss>`
+
+func TestNamespaces(t *testing.T) {
+	feed, err := Parse([]byte(mixedNamespaceFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if feed.Title != "Mixed" {
+		t.Errorf("Expected title %q, got %q", "Mixed", feed.Title)
+	}
+	if want := "https://example.com/"; feed.Link != want {
+		t.Errorf("Expected link %q, got %q", want, feed.Link)
+	}
+	if want := "https://example.com/feed.xml"; feed.SelfURL != want {
+		t.Errorf("Expected self URL %q, got %q", want, feed.SelfURL)
+	}
+	if len(feed.Links) != 2 || feed.Links[1].Rel != "hub" || feed.Links[1].Href != "https://hub.example/" {
+		t.Errorf("Expected self and hub links, got %v", feed.Links)
+	}
+
+	item := feed.Items[0]
+	if want := time.Date(2018, 3, 4, 9, 30, 0, 0, time.UTC); !item.Date.Equal(want) {
+		t.Errorf("Expected date %v, got %v", want, item.Date)
+	}
+	if item.Content != "" {
+		t.Errorf("Expected no content, got %q", item.Content)
+	}
+	if len(item.Links) != 1 || item.Links[0].Rel != "replies" || item.Links[0].Type != "text/html" {
+		t.Errorf("Expected a replies link, got %v", item.Links)
+	}
+	if len(item.Authors) != 1 || item.Authors[0].Name != "Undeclared Prefix" {
+		t.Errorf("Expected author from dc:creator, got %v", item.Authors)
+	}
+}
+
+func TestRSS2DefaultNamespace(t *testing.T) {
+	for _, space := range []string{"http://backend.userland.com/rss2", "http://blogs.law.harvard.edu/tech/rss", "http://example.com/unknown-rss"} {
+		data := `<?xml version="1.0"?>
+<rss version="2.0" xmlns="` + space + `">
+<channel>
+<title>Declared</title>
+<link>https://example.com/</link>
+<item><guid>1</guid><title>First</title></item>
+</channel>
+</rss>`
+
+		feed, err := Parse([]byte(data))
+		if err != nil {
+			t.Errorf("%s: parsing: %v", space, err)
+			continue
+		}
+		if feed.Title != "Declared" || len(feed.Items) != 1 || feed.Items[0].Title != "First" {
+			t.Errorf("%s: expected title and item, got %q and %d items", space, feed.Title, len(feed.Items))
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ParseOptions configures how a single feed is parsed, in place of the
+// package variables such as DefaultRefreshInterval, TimeLayouts and
+// DefaultLimits. Fields left zero use the package defaults, so a nil or
+// empty *ParseOptions behaves like Parse.
+type ParseOptions struct {
+	// Lenient accepts XML that is not well-formed instead of failing.
+	// Data before the XML declaration is skipped, HTML entities such as
+	// &nbsp; are decoded, unescaped ampersands and unknown entities are
+	// kept as text, and HTML elements such as <br> are closed if left
+	// open. What was repaired is reported in Feed.Repairs.
+	Lenient bool
+
+	// BaseURL is the URL of the feed, used to resolve its relative links.
+	// When fetching, it defaults to the URL the feed was fetched from,
+	// after any redirects.
+	BaseURL string
+
+	// Location is the time zone of dates that do not give one. The
+	// default is UTC.
+	Location *time.Location
+
+	// MaxItems is the number of items kept from the feed. Any further
+	// items are dropped. Unlike Limits.MaxItems, this is not an error.
+	MaxItems int
+
+	// TimeLayouts are tried before the package TimeLayouts when parsing
+	// dates.
+	TimeLayouts []string
+
+	// RefreshPolicy combines the refresh times declared by the feed and
+	// by the HTTP response. Its zero value, RefreshDefault, uses
+	// DefaultRefreshPolicy.
+	RefreshPolicy RefreshPolicy
+
+	// RefreshInterval is used in place of DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// Limits is used in place of DefaultLimits.
+	Limits *Limits
+
+	// Logger is used in place of DefaultLogger.
+	Logger *slog.Logger
+}
+
+// FetchOptions configures how a feed is fetched and parsed. The options
+// are kept in Feed.Options, so later updates use them too.
+type FetchOptions struct {
+	ParseOptions
+
+	// RequestFunc performs the HTTP requests. The default is
+	// DefaultRequestFunc.
+	RequestFunc RequestFunc
+}
+
+// ParseWithOptions parses RSS or Atom data as configured by opts.
+func ParseWithOptions(data []byte, opts *ParseOptions) (*Feed, error) {
+	out, err := parse(bytes.NewReader(data), "", opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Refresh.IsZero() {
+		out.Refresh = time.Now().Add(opts.refreshInterval())
+	}
+
+	return out, nil
+}
+
+// FetchWithOptions downloads and parses the feed at the given URL as
+// configured by opts.
+func FetchWithOptions(url string, opts *FetchOptions) (*Feed, error) {
+	return FetchWithOptionsContext(context.Background(), url, opts)
+}
+
+// FetchWithOptionsContext is like FetchWithOptions, but the requests carry
+// ctx.
+func FetchWithOptionsContext(ctx context.Context, url string, opts *FetchOptions) (*Feed, error) {
+	if opts == nil {
+		opts = new(FetchOptions)
+	}
+	requestFunc := opts.RequestFunc
+	if requestFunc == nil {
+		requestFunc = DefaultRequestFunc
+	}
+
+	out, err := fetchByRequestFunc(ctx, requestFunc, url, &opts.ParseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	out.RequestFunc = requestFunc
+	out.Options = &opts.ParseOptions
+
+	return out, nil
+}
+
+func (o *ParseOptions) logger() *slog.Logger {
+	switch {
+	case o != nil && o.Logger != nil:
+		return o.Logger
+	case DefaultLogger != nil:
+		return DefaultLogger
+	}
+	return discardLogger
+}
+
+// withLogger returns a copy of o that logs to l.
+func (o *ParseOptions) withLogger(l *slog.Logger) *ParseOptions {
+	var out ParseOptions
+	if o != nil {
+		out = *o
+	}
+	out.Logger = l
+	return &out
+}
+
+func (o *ParseOptions) lenient() bool {
+	return o != nil && o.Lenient
+}
+
+func (o *ParseOptions) limits() Limits {
+	if o == nil || o.Limits == nil {
+		return DefaultLimits
+	}
+	return *o.Limits
+}
+
+func (o *ParseOptions) location() *time.Location {
+	if o == nil || o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+func (o *ParseOptions) timeLayouts() []string {
+	if o == nil {
+		return nil
+	}
+	return o.TimeLayouts
+}
+
+func (o *ParseOptions) refreshInterval() time.Duration {
+	if o == nil || o.RefreshInterval == 0 {
+		return DefaultRefreshInterval
+	}
+	return o.RefreshInterval
+}
+
+// refresh combines the refresh times declared by the feed and by the HTTP
+// response, falling back to the refresh interval if neither declares one.
+func (o *ParseOptions) refresh(feed, http time.Time) time.Time {
+	policy := RefreshDefault
+	if o != nil {
+		policy = o.RefreshPolicy
+	}
+
+	out := policy.combine(feed, http)
+	if out.IsZero() {
+		out = time.Now().Add(o.refreshInterval())
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package rss
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const optionsFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+<title>Options</title>
+<link>/blog/</link>
+<item><guid>1</guid><link>posts/1</link><pubDate>Sun, 4 Mar 2018 09:30:00</pubDate></item>
+<item><guid>2</guid><link>https://other.example/2</link><pubDate>04.03.2018 09:30</pubDate></item>
+<item><guid>3</guid><link>posts/3</link></item>
+</channel>
+</rss>`
+
+func TestParseWithOptions(t *testing.T) {
+	loc := time.FixedZone("CET", 60*60)
+	opts := &ParseOptions{
+		BaseURL:         "https://example.com/feeds/main.xml",
+		Location:        loc,
+		MaxItems:        2,
+		TimeLayouts:     []string{"02.01.2006 15:04"},
+		RefreshInterval: time.Minute,
+	}
+
+	feed, err := ParseWithOptions([]byte(optionsFeed), opts)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if want := "https://example.com/blog/"; feed.Link != want {
+		t.Errorf("Expected feed link %q, got %q", want, feed.Link)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+	}
+	// Items are resolved against the channel link.
+	if want := "https://example.com/blog/posts/1"; feed.Items[0].Link != want {
+		t.Errorf("Expected item link %q, got %q", want, feed.Items[0].Link)
+	}
+	if want := "https://other.example/2"; feed.Items[1].Link != want {
+		t.Errorf("Expected item link %q, got %q", want, feed.Items[1].Link)
+	}
+
+	want := time.Date(2018, 3, 4, 9, 30, 0, 0, loc)
+	for i, item := range feed.Items {
+		if !item.DateValid || !item.Date.Equal(want) {
+			t.Errorf("Item %d: expected date %v, got %v (valid %v)", i, want, item.Date, item.DateValid)
+		}
+	}
+
+	if feed.Refresh.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected refresh within a minute, got %v", feed.Refresh)
+	}
+
+	// The package defaults are unchanged.
+	feed, err = Parse([]byte(optionsFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if len(feed.Items) != 3 || feed.Items[0].Link != "/blog/posts/1" || feed.Items[1].DateValid {
+		t.Errorf("Expected options not to affect Parse, got %d items", len(feed.Items))
+	}
+}
+
+func TestFetchWithOptions(t *testing.T) {
+	limits := &Limits{MaxBytes: 64}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(optionsFeed))
+	}))
+	defer server.Close()
+
+	opts := &FetchOptions{RequestFunc: server.Client().Do}
+	opts.RefreshInterval = -time.Minute
+	feed, err := FetchWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatalf("Fetching: %v", err)
+	}
+	if len(feed.Items) != 3 {
+		t.Errorf("Expected 3 items, got %d", len(feed.Items))
+	}
+
+	// Updates use the options the feed was fetched with.
+	feed.Options.Limits = limits
+	if err := feed.Update(); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected update to exceed MaxBytes, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
@@ -0,0 +1,272 @@
+package rss
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// A Parser holds the state of parsing a single feed, and is passed to the
+// ParseFunc of the feed's format.
+type Parser struct {
+	out    *Feed
+	format Format
+	opts   *ParseOptions // May be nil.
+	log    *slog.Logger
+	limits Limits
+	fn     func(*Item) error // Receives items instead of out.Items, if set.
+	err    error             // The error returned by fn, if any.
+	lines  *lineIndex
+
+	base     string // The URL of the document, if known.
+	itemBase string // Resolves the next item's links, relative to base.
+
+	repairs   repairLog // Made by lenient parsing.
+	junkLines int       // Lines skipped before the XML declaration.
+
+	items  int  // Items read so far, whether kept or dropped.
+	capped bool // Whether items beyond MaxItems have been dropped.
+}
+
+func newParser(lines *lineIndex, format Format, opts *ParseOptions, fn func(*Item) error) *Parser {
+	out := new(Feed)
+	out.Items = make([]*Item, 0)
+	out.ItemMap = make(map[string]struct{})
+	out.Format = format
+	log := opts.logger().With("format", format.String())
+	p := &Parser{out: out, format: format, opts: opts, log: log, limits: opts.limits(), fn: fn, lines: lines}
+	if opts != nil {
+		p.base = opts.BaseURL
+	}
+	return p
+}
+
+// Feed returns the feed being parsed. A ParseFunc should fill in its
+// fields and return it, but add items with Emit.
+func (p *Parser) Feed() *Feed {
+	return p.out
+}
+
+// Format returns the detected format of the feed being parsed.
+func (p *Parser) Format() Format {
+	return p.format
+}
+
+// ParseTime parses a date in any of the configured time layouts.
+func (p *Parser) ParseTime(s string) (time.Time, error) {
+	return parseTimeIn(s, p.opts.timeLayouts(), p.opts.location())
+}
+
+// Emit adds item to the feed as soon as it has been read. Items whose ID
+// has already been seen, and items beyond ParseOptions.MaxItems, are
+// dropped. If Emit returns an error, the ParseFunc should stop and return
+// it.
+func (p *Parser) Emit(item *Item) error {
+	if _, ok := p.out.ItemMap[item.ID]; ok {
+		p.drop(item.ID, WarnDuplicateID, "", fmt.Sprintf("item %q repeats ID %q", item.Title, item.ID))
+		return nil
+	}
+
+	if p.opts != nil && p.opts.MaxItems > 0 && len(p.out.ItemMap) >= p.opts.MaxItems {
+		if !p.capped {
+			p.capped = true
+			p.Drop(WarnItemLimit, "", fmt.Sprintf("items after the first %d were dropped", p.opts.MaxItems))
+		} else {
+			p.items++
+		}
+		return nil
+	}
+
+	if err := p.limits.checkItem(item, len(p.out.ItemMap)); err != nil {
+		p.err = err
+		return err
+	}
+
+	p.items++
+	for _, enclosure := range item.Enclosures {
+		enclosure.guessType()
+	}
+	p.resolveItem(item)
+	p.out.ItemMap[item.ID] = struct{}{}
+	p.out.Unread++
+
+	if p.fn == nil {
+		p.out.Items = append(p.out.Items, item)
+		return nil
+	}
+
+	p.err = p.fn(item)
+	return p.err
+}
+
+// DecodeXML decodes the XML document read from r into v, applying the
+// parser's limits and reporting errors as a *ParseError. In lenient mode,
+// malformed XML is repaired where possible and the repairs are recorded.
+func (p *Parser) DecodeXML(r io.Reader, v interface{}) error {
+	return p.decodeXML(r, v, "", nil)
+}
+
+// decodeXML is like DecodeXML, but puts elements in the namespaces in own
+// into space.
+func (p *Parser) decodeXML(r io.Reader, v interface{}, space string, own []string) error {
+	var s *entityScanner
+	if p.opts.lenient() {
+		s = newEntityScanner(r, &p.repairs, 1+p.junkLines)
+		r = s
+	}
+
+	d := xml.NewDecoder(r)
+	d.CharsetReader = charsetReader
+
+	var t xml.TokenReader = d
+	if s != nil {
+		d.Strict = false
+		d.AutoClose = lenientAutoClose
+		d.Entity = xml.HTMLEntity
+		t = &closeTracker{d: d, s: s, log: &p.repairs}
+	}
+	if p.limits.MaxDepth > 0 {
+		t = &depthLimiter{t: t, max: p.limits.MaxDepth}
+	}
+	t = &xmlBaseTracker{t: t, base: p.base}
+	t = newNamespaceReader(t, space, own)
+
+	outer := xml.NewTokenDecoder(t)
+
+	if err := outer.Decode(v); err != nil {
+		return p.decodeError(d.InputOffset(), err)
+	}
+	return nil
+}
+
+// resolveItem resolves the item's relative links against itemBase, and
+// the document's URL. Links inside HTML content are resolved too.
+func (p *Parser) resolveItem(item *Item) {
+	base := resolveURL(p.base, p.itemBase)
+	if base == "" {
+		return
+	}
+	item.Link = resolveURL(base, item.Link)
+	item.ContentURL = resolveURL(base, item.ContentURL)
+	if item.Image != nil {
+		item.Image.URL = resolveURL(base, item.Image.URL)
+	}
+	if item.Banner != nil {
+		item.Banner.URL = resolveURL(base, item.Banner.URL)
+	}
+	for _, enclosure := range item.Enclosures {
+		enclosure.URL = resolveURL(base, enclosure.URL)
+	}
+	for _, link := range item.Links {
+		link.Href = resolveURL(base, link.Href)
+	}
+	if item.ITunes != nil {
+		item.ITunes.Image = resolveURL(base, item.ITunes.Image)
+	}
+	if item.Podcast != nil {
+		item.Podcast.resolve(base)
+	}
+	if item.Media != nil {
+		item.Media.resolve(base)
+	}
+	if item.SummaryType == ContentTypeHTML {
+		item.Summary = resolveHTML(item.Summary, base)
+	}
+	if item.ContentType == ContentTypeHTML {
+		item.Content = resolveHTML(item.Content, base)
+	}
+}
+
+// resolveFeed resolves the feed's relative links against the document's
+// URL.
+func (p *Parser) resolveFeed() {
+	p.out.Link = resolveURL(p.base, p.out.Link)
+	p.out.SelfURL = resolveURL(p.base, p.out.SelfURL)
+	p.out.NextURL = resolveURL(p.base, p.out.NextURL)
+	for _, link := range p.out.Links {
+		link.Href = resolveURL(p.base, link.Href)
+	}
+	for _, hub := range p.out.Hubs {
+		hub.URL = resolveURL(p.base, hub.URL)
+	}
+	if p.out.ITunes != nil {
+		p.out.ITunes.Image = resolveURL(p.base, p.out.ITunes.Image)
+		p.out.ITunes.NewFeedURL = resolveURL(p.base, p.out.ITunes.NewFeedURL)
+	}
+	if p.out.Podcast != nil {
+		p.out.Podcast.resolve(p.base)
+	}
+	if p.out.Image != nil {
+		p.out.Image.URL = resolveURL(p.base, p.out.Image.URL)
+	}
+	p.out.Favicon = resolveURL(p.base, p.out.Favicon)
+}
+
+// decodeError converts err, returned by a decoder at the given offset, into
+// a *ParseError. Errors returned by p.fn, exceeded limits and errors
+// reading the data, such as a lost connection, are passed through
+// unchanged, as they say nothing about whether the feed can be parsed.
+func (p *Parser) decodeError(offset int64, err error) error {
+	if p.err != nil {
+		return p.err
+	}
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		return limitErr
+	}
+	if p.lines.err != nil && errors.Is(err, p.lines.err) {
+		return err
+	}
+	return newParseError(p.format.String(), p.lines, offset, err)
+}
+
+// An elementFunc is called to decode each occurrence of an element, in
+// place of collecting them all into a slice. This lets items be handed out
+// while the rest of the document is still being read.
+type elementFunc func(d *xml.Decoder, start xml.StartElement) error
+
+func (f elementFunc) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return f(d, start)
+}
+
+// lineIndex records where lines end in the data read through it, so that
+// byte offsets can later be turned into line and column numbers without
+// keeping the data itself.
+type lineIndex struct {
+	r        io.Reader
+	n        int64
+	newlines []int64
+	err      error // The first error other than io.EOF returned by r.
+}
+
+func (l *lineIndex) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	if err != nil && err != io.EOF && l.err == nil {
+		l.err = err
+	}
+	for i, c := range b[:n] {
+		if c == '\n' {
+			l.newlines = append(l.newlines, l.n+int64(i))
+		}
+	}
+	l.n += int64(n)
+	return n, err
+}
+
+// position returns the 1-based line and column of offset, or zeros if
+// offset has not been read.
+func (l *lineIndex) position(offset int64) (line, column int) {
+	if offset < 0 || offset > l.n {
+		return 0, 0
+	}
+	i := sort.Search(len(l.newlines), func(i int) bool { return l.newlines[i] >= offset })
+	start := int64(0)
+	if i > 0 {
+		start = l.newlines[i-1] + 1
+	}
+	return i + 1, int(offset-start) + 1
+}
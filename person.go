@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Person is an author or contributor of a feed or item.
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	URI   string `json:"uri"`
+	Image string `json:"image"` // Such as a JSON Feed avatar.
+}
+
+func (p *Person) String() string {
+	switch {
+	case p.Name != "" && p.Email != "":
+		return p.Name + " <" + p.Email + ">"
+	case p.Name != "":
+		return p.Name
+	case p.Email != "":
+		return p.Email
+	}
+	return p.URI
+}
+
+var (
+	// personEmailFirst matches RSS people written as "email (Name)".
+	personEmailFirst = regexp.MustCompile(`^(\S+@\S+)\s*\((.*)\)$`)
+
+	// personNameFirst matches people written as "Name <email>".
+	personNameFirst = regexp.MustCompile(`^(.*?)\s*<(\S+@[^\s>]+)>$`)
+)
+
+// parsePerson parses a person given as text, such as the RSS <author> and
+// <managingEditor> elements, which hold an email address optionally
+// followed by a name in parentheses. It returns nil if s is empty.
+func parsePerson(s string) *Person {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	if m := personEmailFirst.FindStringSubmatch(s); m != nil {
+		return &Person{Name: strings.TrimSpace(m[2]), Email: strings.TrimPrefix(m[1], "mailto:")}
+	}
+	if m := personNameFirst.FindStringSubmatch(s); m != nil {
+		return &Person{Name: strings.Trim(m[1], `" `), Email: strings.TrimPrefix(m[2], "mailto:")}
+	}
+	if !strings.ContainsAny(s, " \t") && strings.Contains(s, "@") {
+		return &Person{Email: strings.TrimPrefix(s, "mailto:")}
+	}
+	return &Person{Name: s}
+}
+
+// appendPeople appends to people each of add that is not nil and not
+// already present.
+func appendPeople(people []*Person, add ...*Person) []*Person {
+	for _, p := range add {
+		if p == nil || *p == (Person{}) || hasPerson(people, p) {
+			continue
+		}
+		people = append(people, p)
+	}
+	return people
+}
+
+// hasPerson reports whether p adds nothing to one of people.
+func hasPerson(people []*Person, p *Person) bool {
+	for _, q := range people {
+		if *q == *p {
+			return true
+		}
+		if p.Name != "" && p.Name == q.Name && (p.Email == "" || p.Email == q.Email) && (p.URI == "" || p.URI == q.URI) && (p.Image == "" || p.Image == q.Image) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorName returns a name for the first of people, for Feed.Author.
+func authorName(people []*Person) string {
+	if len(people) == 0 {
+		return ""
+	}
+	return people[0].String()
+}
@@ -0,0 +1,123 @@
+package rss
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePerson(t *testing.T) {
+	tests := map[string]*Person{
+		"":                                     nil,
+		"Jane Doe":                             {Name: "Jane Doe"},
+		"jane@example.com":                     {Email: "jane@example.com"},
+		"jane@example.com (Jane Doe)":          {Name: "Jane Doe", Email: "jane@example.com"},
+		"Jane Doe <jane@example.com>":          {Name: "Jane Doe", Email: "jane@example.com"},
+		`"Jane Doe" <mailto:jane@example.com>`: {Name: "Jane Doe", Email: "jane@example.com"},
+	}
+	for in, want := range tests {
+		if got := parsePerson(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("parsePerson(%q): got %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestParsePeople(t *testing.T) {
+	tests := []struct {
+		name        string
+		feedAuthors []*Person
+		itemAuthors []*Person
+	}{{
+		name:        "atom_1.0",
+		feedAuthors: []*Person{{Name: "Autor des Weblogs"}},
+		itemAuthors: []*Person{{Name: "Autor des Weblogs"}},
+	}, {
+		name:        "jsonfeed_v1",
+		feedAuthors: []*Person{{Name: "Brent Simmons and Manton Reece", URI: "https://jsonfeed.org/"}},
+		itemAuthors: []*Person{{Name: "Brent Simmons and Manton Reece", URI: "https://jsonfeed.org/"}},
+	}, {
+		name:        "rss_0.91",
+		feedAuthors: []*Person{{Email: "editor@writetheweb.com"}},
+	}, {
+		name:        "rss_1.0",
+		itemAuthors: []*Person{{Name: "Jörg Thoma"}},
+	}}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", test.name))
+		if err != nil {
+			t.Fatalf("Reading %s: %v", test.name, err)
+		}
+		feed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(feed.Authors, test.feedAuthors) {
+			t.Errorf("%s: expected feed authors %v, got %v", test.name, test.feedAuthors, feed.Authors)
+		}
+		if len(feed.Items) == 0 {
+			t.Fatalf("%s: expected items", test.name)
+		}
+		if got := feed.Items[0].Authors; !reflect.DeepEqual(got, test.itemAuthors) {
+			t.Errorf("%s: expected item authors %v, got %v", test.name, test.itemAuthors, got)
+		}
+	}
+}
+
+func TestParseContributors(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>People</title>
+<author><name>Feed Author</name></author>
+<entry>
+<id>1</id>
+<author><name>Jane Doe</name><email>jane@example.com</email><uri>https://jane.example/</uri></author>
+<contributor><name>John Roe</name></contributor>
+</entry>
+</feed>`)
+
+	feed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if feed.Author != "Feed Author" {
+		t.Errorf("Expected author %q, got %q", "Feed Author", feed.Author)
+	}
+
+	item := feed.Items[0]
+	want := []*Person{{Name: "Jane Doe", Email: "jane@example.com", URI: "https://jane.example/"}}
+	if !reflect.DeepEqual(item.Authors, want) {
+		t.Errorf("Expected authors %v, got %v", want, item.Authors)
+	}
+	want = []*Person{{Name: "John Roe"}}
+	if !reflect.DeepEqual(item.Contributors, want) {
+		t.Errorf("Expected contributors %v, got %v", want, item.Contributors)
+	}
+}
+
+func TestParseJSONFeedAuthors(t *testing.T) {
+	data := []byte(`{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "People",
+	"authors": [{"name": "Jane Doe"}, {"name": "John Roe", "url": "https://john.example/"}],
+	"items": [{"id": "1"}, {"id": "2", "authors": [{"name": "Guest"}]}]
+}`)
+
+	feed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	want := []*Person{{Name: "Jane Doe"}, {Name: "John Roe", URI: "https://john.example/"}}
+	if !reflect.DeepEqual(feed.Authors, want) {
+		t.Errorf("Expected feed authors %v, got %v", want, feed.Authors)
+	}
+	if !reflect.DeepEqual(feed.Items[0].Authors, want) {
+		t.Errorf("Expected inherited authors %v, got %v", want, feed.Items[0].Authors)
+	}
+	if want := []*Person{{Name: "Guest"}}; !reflect.DeepEqual(feed.Items[1].Authors, want) {
+		t.Errorf("Expected item authors %v, got %v", want, feed.Items[1].Authors)
+	}
+}
@@ -0,0 +1,443 @@
+package rss
+
+import (
+	"strings"
+	"time"
+)
+
+// PodcastFeed is the metadata of a feed given by the Podcasting 2.0
+// namespace. See https://podcastindex.org/namespace/1.0.
+type PodcastFeed struct {
+	GUID        string            `json:"guid"`        // Identifies the podcast wherever it is hosted.
+	Locked      bool              `json:"locked"`      // The podcast may not be imported by other platforms.
+	LockedOwner string            `json:"lockedowner"` // Email address of the owner who may unlock it.
+	Funding     []*PodcastFunding `json:"funding"`
+	Persons     []*PodcastPerson  `json:"persons"`
+	Location    *PodcastLocation  `json:"location"`
+	Value       []*PodcastValue   `json:"value"`
+}
+
+// PodcastItem is the metadata of an episode given by the Podcasting 2.0
+// namespace.
+type PodcastItem struct {
+	Transcripts         []*PodcastTranscript         `json:"transcripts"`
+	Chapters            *PodcastChapters             `json:"chapters"`
+	Soundbites          []*PodcastSoundbite          `json:"soundbites"`
+	Persons             []*PodcastPerson             `json:"persons"`
+	Location            *PodcastLocation             `json:"location"`
+	Season              *PodcastSeason               `json:"season"`
+	Episode             *PodcastEpisode              `json:"episode"`
+	AlternateEnclosures []*PodcastAlternateEnclosure `json:"alternateenclosures"`
+	Value               []*PodcastValue              `json:"value"`
+}
+
+// PodcastTranscript links to a transcript of an episode.
+type PodcastTranscript struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"` // MIME type, such as "text/vtt".
+	Language string `json:"language"`
+	Rel      string `json:"rel"` // "captions" if the transcript can be used as captions.
+}
+
+// PodcastChapters links to the chapters of an episode.
+type PodcastChapters struct {
+	URL  string `json:"url"`
+	Type string `json:"type"` // MIME type, such as "application/json+chapters".
+}
+
+// PodcastFunding links to where the podcast can be supported.
+type PodcastFunding struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// PodcastPerson is someone involved in a podcast or episode.
+type PodcastPerson struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`  // Such as "host" or "guest".
+	Group string `json:"group"` // Such as "cast" or "writing".
+	Image string `json:"image"`
+	Href  string `json:"href"`
+}
+
+// PodcastLocation is the place a podcast or episode is about.
+type PodcastLocation struct {
+	Name string `json:"name"`
+	Geo  string `json:"geo"` // A geo: URI.
+	OSM  string `json:"osm"` // An OpenStreetMap ID.
+}
+
+// PodcastSoundbite is a part of an episode suitable for sharing.
+type PodcastSoundbite struct {
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Title    string        `json:"title"`
+}
+
+// PodcastSeason is the season of an episode.
+type PodcastSeason struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+}
+
+// PodcastEpisode is the number of an episode, which need not be a whole
+// number. Display, if set, is shown in its place.
+type PodcastEpisode struct {
+	Number  float64 `json:"number"`
+	Display string  `json:"display"`
+}
+
+// PodcastAlternateEnclosure is a version of an episode's media other than
+// the enclosure, such as one at a lower bitrate. Each source is another
+// place to download it from.
+type PodcastAlternateEnclosure struct {
+	Type    string       `json:"type"`
+	Length  uint         `json:"length"`
+	Bitrate float64      `json:"bitrate"`
+	Height  uint         `json:"height"`
+	Lang    string       `json:"lang"`
+	Title   string       `json:"title"`
+	Rel     string       `json:"rel"`
+	Codecs  string       `json:"codecs"`
+	Default bool         `json:"default"`
+	Sources []*Enclosure `json:"sources"`
+}
+
+// PodcastValue describes how listeners can send payments to the people
+// behind a podcast.
+type PodcastValue struct {
+	Type       string                   `json:"type"`   // Such as "lightning".
+	Method     string                   `json:"method"` // Such as "keysend".
+	Suggested  string                   `json:"suggested"`
+	Recipients []*PodcastValueRecipient `json:"recipients"`
+}
+
+// PodcastValueRecipient receives a share of payments. Shares are split in
+// proportion to Split.
+type PodcastValueRecipient struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Address     string `json:"address"`
+	Split       int    `json:"split"`
+	Fee         bool   `json:"fee"`
+	CustomKey   string `json:"customkey"`
+	CustomValue string `json:"customvalue"`
+}
+
+// podcastChannel holds the Podcasting 2.0 elements of a channel.
+type podcastChannel struct {
+	PodcastGUID     string           `xml:"https://podcastindex.org/namespace/1.0 guid"`
+	PodcastLocked   podcastLocked    `xml:"https://podcastindex.org/namespace/1.0 locked"`
+	PodcastFunding  []podcastFunding `xml:"https://podcastindex.org/namespace/1.0 funding"`
+	PodcastPersons  []podcastPerson  `xml:"https://podcastindex.org/namespace/1.0 person"`
+	PodcastLocation *podcastLocation `xml:"https://podcastindex.org/namespace/1.0 location"`
+	PodcastValue    []podcastValue   `xml:"https://podcastindex.org/namespace/1.0 value"`
+}
+
+// podcastItem holds the Podcasting 2.0 elements of an item.
+type podcastItem struct {
+	PodcastTranscripts         []podcastTranscript         `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+	PodcastChapters            *podcastChapters            `xml:"https://podcastindex.org/namespace/1.0 chapters"`
+	PodcastSoundbites          []podcastSoundbite          `xml:"https://podcastindex.org/namespace/1.0 soundbite"`
+	PodcastPersons             []podcastPerson             `xml:"https://podcastindex.org/namespace/1.0 person"`
+	PodcastLocation            *podcastLocation            `xml:"https://podcastindex.org/namespace/1.0 location"`
+	PodcastSeason              *podcastSeason              `xml:"https://podcastindex.org/namespace/1.0 season"`
+	PodcastEpisode             *podcastEpisode             `xml:"https://podcastindex.org/namespace/1.0 episode"`
+	PodcastAlternateEnclosures []podcastAlternateEnclosure `xml:"https://podcastindex.org/namespace/1.0 alternateEnclosure"`
+	PodcastValue               []podcastValue              `xml:"https://podcastindex.org/namespace/1.0 value"`
+}
+
+// See https://podcastindex.org/namespace/1.0#locked for details.
+type podcastLocked struct {
+	Owner    string `xml:"owner,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+// See https://podcastindex.org/namespace/1.0#funding for details.
+type podcastFunding struct {
+	URL      string `xml:"url,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+func (r *podcastFunding) Funding() *PodcastFunding {
+	return &PodcastFunding{URL: strings.TrimSpace(r.URL), Text: strings.TrimSpace(r.Chardata)}
+}
+
+// See https://podcastindex.org/namespace/1.0#person for details.
+type podcastPerson struct {
+	Role     string `xml:"role,attr"`
+	Group    string `xml:"group,attr"`
+	Img      string `xml:"img,attr"`
+	Href     string `xml:"href,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+func (r *podcastPerson) Person() *PodcastPerson {
+	out := new(PodcastPerson)
+	out.Name = strings.TrimSpace(r.Chardata)
+	// The role and group default to the host of the podcast.
+	out.Role = strings.ToLower(strings.TrimSpace(r.Role))
+	if out.Role == "" {
+		out.Role = "host"
+	}
+	out.Group = strings.ToLower(strings.TrimSpace(r.Group))
+	if out.Group == "" {
+		out.Group = "cast"
+	}
+	out.Image = strings.TrimSpace(r.Img)
+	out.Href = strings.TrimSpace(r.Href)
+	return out
+}
+
+// See https://podcastindex.org/namespace/1.0#location for details.
+type podcastLocation struct {
+	Geo      string `xml:"geo,attr"`
+	OSM      string `xml:"osm,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+func (r *podcastLocation) Location() *PodcastLocation {
+	if r == nil {
+		return nil
+	}
+	return &PodcastLocation{Name: strings.TrimSpace(r.Chardata), Geo: strings.TrimSpace(r.Geo), OSM: strings.TrimSpace(r.OSM)}
+}
+
+// See https://podcastindex.org/namespace/1.0#value for details.
+type podcastValue struct {
+	Type       string                  `xml:"type,attr"`
+	Method     string                  `xml:"method,attr"`
+	Suggested  string                  `xml:"suggested,attr"`
+	Recipients []podcastValueRecipient `xml:"https://podcastindex.org/namespace/1.0 valueRecipient"`
+}
+
+// See https://podcastindex.org/namespace/1.0#value-recipient for details.
+type podcastValueRecipient struct {
+	Name        string `xml:"name,attr"`
+	Type        string `xml:"type,attr"`
+	Address     string `xml:"address,attr"`
+	Split       string `xml:"split,attr"`
+	Fee         string `xml:"fee,attr"`
+	CustomKey   string `xml:"customKey,attr"`
+	CustomValue string `xml:"customValue,attr"`
+}
+
+func (r *podcastValue) Value(p *Parser, item int, id string) *PodcastValue {
+	out := new(PodcastValue)
+	out.Type = strings.TrimSpace(r.Type)
+	out.Method = strings.TrimSpace(r.Method)
+	out.Suggested = strings.TrimSpace(r.Suggested)
+	for _, recipient := range r.Recipients {
+		out.Recipients = append(out.Recipients, &PodcastValueRecipient{
+			Name:        strings.TrimSpace(recipient.Name),
+			Type:        strings.TrimSpace(recipient.Type),
+			Address:     strings.TrimSpace(recipient.Address),
+			Split:       p.parseInt(item, id, "valueRecipient", recipient.Split),
+			Fee:         strings.EqualFold(strings.TrimSpace(recipient.Fee), "true"),
+			CustomKey:   recipient.CustomKey,
+			CustomValue: recipient.CustomValue,
+		})
+	}
+	return out
+}
+
+// See https://podcastindex.org/namespace/1.0#transcript for details.
+type podcastTranscript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr"`
+	Rel      string `xml:"rel,attr"`
+}
+
+func (r *podcastTranscript) Transcript() *PodcastTranscript {
+	return &PodcastTranscript{
+		URL:      strings.TrimSpace(r.URL),
+		Type:     strings.TrimSpace(r.Type),
+		Language: strings.TrimSpace(r.Language),
+		Rel:      strings.TrimSpace(r.Rel),
+	}
+}
+
+// See https://podcastindex.org/namespace/1.0#chapters for details.
+type podcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+func (r *podcastChapters) Chapters() *PodcastChapters {
+	if r == nil {
+		return nil
+	}
+	return &PodcastChapters{URL: strings.TrimSpace(r.URL), Type: strings.TrimSpace(r.Type)}
+}
+
+// See https://podcastindex.org/namespace/1.0#soundbite for details.
+type podcastSoundbite struct {
+	StartTime string `xml:"startTime,attr"`
+	Duration  string `xml:"duration,attr"`
+	Chardata  string `xml:",chardata"`
+}
+
+func (r *podcastSoundbite) Soundbite(p *Parser, id string) *PodcastSoundbite {
+	out := new(PodcastSoundbite)
+	out.Start = seconds(p.parseFloat(p.items, id, "soundbite", r.StartTime))
+	out.Duration = seconds(p.parseFloat(p.items, id, "soundbite", r.Duration))
+	out.Title = strings.TrimSpace(r.Chardata)
+	return out
+}
+
+// See https://podcastindex.org/namespace/1.0#season for details.
+type podcastSeason struct {
+	Name     string `xml:"name,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+// See https://podcastindex.org/namespace/1.0#episode for details.
+type podcastEpisode struct {
+	Display  string `xml:"display,attr"`
+	Chardata string `xml:",chardata"`
+}
+
+// See https://podcastindex.org/namespace/1.0#alternate-enclosure for
+// details. Numbers are parsed leniently, so that one bad attribute does
+// not stop the feed being read.
+type podcastAlternateEnclosure struct {
+	Type    string          `xml:"type,attr"`
+	Length  string          `xml:"length,attr"`
+	Bitrate string          `xml:"bitrate,attr"`
+	Height  string          `xml:"height,attr"`
+	Lang    string          `xml:"lang,attr"`
+	Title   string          `xml:"title,attr"`
+	Rel     string          `xml:"rel,attr"`
+	Codecs  string          `xml:"codecs,attr"`
+	Default string          `xml:"default,attr"`
+	Sources []podcastSource `xml:"https://podcastindex.org/namespace/1.0 source"`
+}
+
+// See https://podcastindex.org/namespace/1.0#source for details.
+type podcastSource struct {
+	URI         string `xml:"uri,attr"`
+	ContentType string `xml:"contentType,attr"`
+}
+
+func (r *podcastAlternateEnclosure) AlternateEnclosure(p *Parser, id string) *PodcastAlternateEnclosure {
+	out := new(PodcastAlternateEnclosure)
+	out.Type = strings.TrimSpace(r.Type)
+	out.Length = uint(p.parseInt(p.items, id, "length", r.Length))
+	out.Bitrate = p.parseFloat(p.items, id, "bitrate", r.Bitrate)
+	out.Height = uint(p.parseInt(p.items, id, "height", r.Height))
+	out.Lang = strings.TrimSpace(r.Lang)
+	out.Title = strings.TrimSpace(r.Title)
+	out.Rel = strings.TrimSpace(r.Rel)
+	out.Codecs = strings.TrimSpace(r.Codecs)
+	out.Default = strings.EqualFold(strings.TrimSpace(r.Default), "true")
+	for _, source := range r.Sources {
+		enclosure := &Enclosure{URL: strings.TrimSpace(source.URI), Type: strings.TrimSpace(source.ContentType)}
+		if enclosure.Type == "" {
+			enclosure.Type = out.Type
+		}
+		// The length is that of the media, wherever it comes from.
+		enclosure.Length = out.Length
+		out.Sources = append(out.Sources, enclosure)
+	}
+	return out
+}
+
+// feed returns the channel's Podcasting 2.0 metadata, or nil if it has
+// none. Problems with the values are reported through p.
+func (c *podcastChannel) feed(p *Parser) *PodcastFeed {
+	locked := strings.TrimSpace(c.PodcastLocked.Chardata)
+	if c.PodcastGUID == "" && locked == "" && len(c.PodcastFunding) == 0 && len(c.PodcastPersons) == 0 &&
+		c.PodcastLocation == nil && len(c.PodcastValue) == 0 {
+		return nil
+	}
+
+	out := new(PodcastFeed)
+	out.GUID = strings.TrimSpace(c.PodcastGUID)
+	out.Locked = strings.EqualFold(locked, "yes")
+	out.LockedOwner = strings.TrimSpace(c.PodcastLocked.Owner)
+	for i := range c.PodcastFunding {
+		out.Funding = append(out.Funding, c.PodcastFunding[i].Funding())
+	}
+	for i := range c.PodcastPersons {
+		out.Persons = append(out.Persons, c.PodcastPersons[i].Person())
+	}
+	out.Location = c.PodcastLocation.Location()
+	for i := range c.PodcastValue {
+		out.Value = append(out.Value, c.PodcastValue[i].Value(p, -1, ""))
+	}
+	return out
+}
+
+// item returns the item's Podcasting 2.0 metadata, or nil if it has none.
+// Problems with the values are reported through p.
+func (i *podcastItem) item(p *Parser, id string) *PodcastItem {
+	if len(i.PodcastTranscripts) == 0 && i.PodcastChapters == nil && len(i.PodcastSoundbites) == 0 &&
+		len(i.PodcastPersons) == 0 && i.PodcastLocation == nil && i.PodcastSeason == nil &&
+		i.PodcastEpisode == nil && len(i.PodcastAlternateEnclosures) == 0 && len(i.PodcastValue) == 0 {
+		return nil
+	}
+
+	out := new(PodcastItem)
+	for j := range i.PodcastTranscripts {
+		out.Transcripts = append(out.Transcripts, i.PodcastTranscripts[j].Transcript())
+	}
+	out.Chapters = i.PodcastChapters.Chapters()
+	for j := range i.PodcastSoundbites {
+		out.Soundbites = append(out.Soundbites, i.PodcastSoundbites[j].Soundbite(p, id))
+	}
+	for j := range i.PodcastPersons {
+		out.Persons = append(out.Persons, i.PodcastPersons[j].Person())
+	}
+	out.Location = i.PodcastLocation.Location()
+	if season := i.PodcastSeason; season != nil {
+		out.Season = &PodcastSeason{Number: p.parseInt(p.items, id, "season", season.Chardata), Name: strings.TrimSpace(season.Name)}
+	}
+	if episode := i.PodcastEpisode; episode != nil {
+		out.Episode = &PodcastEpisode{Number: p.parseFloat(p.items, id, "episode", episode.Chardata), Display: strings.TrimSpace(episode.Display)}
+	}
+	for j := range i.PodcastAlternateEnclosures {
+		out.AlternateEnclosures = append(out.AlternateEnclosures, i.PodcastAlternateEnclosures[j].AlternateEnclosure(p, id))
+	}
+	for j := range i.PodcastValue {
+		out.Value = append(out.Value, i.PodcastValue[j].Value(p, p.items, id))
+	}
+	return out
+}
+
+// resolve resolves the feed's links against base.
+func (f *PodcastFeed) resolve(base string) {
+	for _, funding := range f.Funding {
+		funding.URL = resolveURL(base, funding.URL)
+	}
+	for _, person := range f.Persons {
+		person.resolve(base)
+	}
+}
+
+// resolve resolves the item's links against base.
+func (i *PodcastItem) resolve(base string) {
+	for _, transcript := range i.Transcripts {
+		transcript.URL = resolveURL(base, transcript.URL)
+	}
+	if i.Chapters != nil {
+		i.Chapters.URL = resolveURL(base, i.Chapters.URL)
+	}
+	for _, person := range i.Persons {
+		person.resolve(base)
+	}
+	for _, alternate := range i.AlternateEnclosures {
+		for _, source := range alternate.Sources {
+			source.URL = resolveURL(base, source.URL)
+		}
+	}
+}
+
+func (p *PodcastPerson) resolve(base string) {
+	p.Image = resolveURL(base, p.Image)
+	p.Href = resolveURL(base, p.Href)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
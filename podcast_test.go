@@ -0,0 +1,136 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const podcastFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel>
+	<title>A Podcast</title>
+	<link>https://podcast.example/</link>
+	<podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>
+	<podcast:locked owner="owner@podcast.example">yes</podcast:locked>
+	<podcast:funding url="/support">Support the show</podcast:funding>
+	<podcast:person href="https://host.example/" img="host.jpg">A Host</podcast:person>
+	<podcast:location geo="geo:30.2672,97.7431" osm="R113314">Austin, TX</podcast:location>
+	<podcast:value type="lightning" method="keysend" suggested="0.00000005000">
+		<podcast:valueRecipient name="Host" type="node" address="02d5c1bf8b940dc9cadca86d1b0a3c37fbe39cee4c7e839e33bef9174531d27f52" split="90"/>
+		<podcast:valueRecipient name="App" type="node" address="03ae9f91a0cb8ff43840e3c322c4c61f019d8c1c3cea15a25cfc425ac605e61a4a" split="10" fee="true"/>
+	</podcast:value>
+	<item>
+		<guid>1</guid>
+		<title>Episode</title>
+		<podcast:transcript url="https://podcast.example/1.vtt" type="text/vtt" language="en" rel="captions"/>
+		<podcast:chapters url="1.json" type="application/json+chapters"/>
+		<podcast:soundbite startTime="73.0" duration="60.5">The best bit</podcast:soundbite>
+		<podcast:person role="Guest" href="https://guest.example/">A Guest</podcast:person>
+		<podcast:season name="Origins">2</podcast:season>
+		<podcast:episode display="Ch. 3">3.5</podcast:episode>
+		<podcast:alternateEnclosure type="audio/opus" length="1000" bitrate="32000" title="Low bandwidth" default="true">
+			<podcast:source uri="https://cdn.example/1.opus"/>
+			<podcast:source uri="ipfs://QmdwGqd3d2gFPGeJNLLCshdiPert45fMu84552Y4XHTy4y" contentType="audio/ogg"/>
+		</podcast:alternateEnclosure>
+	</item>
+	<item>
+		<guid>2</guid>
+		<title>Plain</title>
+	</item>
+</channel>
+</rss>`
+
+func TestPodcastNamespace(t *testing.T) {
+	feed, err := Parse([]byte(podcastFeed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	want := &PodcastFeed{
+		GUID:        "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+		Locked:      true,
+		LockedOwner: "owner@podcast.example",
+		Funding:     []*PodcastFunding{{URL: "/support", Text: "Support the show"}},
+		Persons:     []*PodcastPerson{{Name: "A Host", Role: "host", Group: "cast", Image: "host.jpg", Href: "https://host.example/"}},
+		Location:    &PodcastLocation{Name: "Austin, TX", Geo: "geo:30.2672,97.7431", OSM: "R113314"},
+		Value: []*PodcastValue{{
+			Type:      "lightning",
+			Method:    "keysend",
+			Suggested: "0.00000005000",
+			Recipients: []*PodcastValueRecipient{
+				{Name: "Host", Type: "node", Address: "02d5c1bf8b940dc9cadca86d1b0a3c37fbe39cee4c7e839e33bef9174531d27f52", Split: 90},
+				{Name: "App", Type: "node", Address: "03ae9f91a0cb8ff43840e3c322c4c61f019d8c1c3cea15a25cfc425ac605e61a4a", Split: 10, Fee: true},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(feed.Podcast, want) {
+		t.Errorf("Expected %+v, got %+v", want, feed.Podcast)
+	}
+
+	wantItem := &PodcastItem{
+		Transcripts: []*PodcastTranscript{{URL: "https://podcast.example/1.vtt", Type: "text/vtt", Language: "en", Rel: "captions"}},
+		Chapters:    &PodcastChapters{URL: "https://podcast.example/1.json", Type: "application/json+chapters"},
+		Soundbites:  []*PodcastSoundbite{{Start: 73 * time.Second, Duration: 60500 * time.Millisecond, Title: "The best bit"}},
+		Persons:     []*PodcastPerson{{Name: "A Guest", Role: "guest", Group: "cast", Href: "https://guest.example/"}},
+		Season:      &PodcastSeason{Number: 2, Name: "Origins"},
+		Episode:     &PodcastEpisode{Number: 3.5, Display: "Ch. 3"},
+		AlternateEnclosures: []*PodcastAlternateEnclosure{{
+			Type:    "audio/opus",
+			Length:  1000,
+			Bitrate: 32000,
+			Title:   "Low bandwidth",
+			Default: true,
+			Sources: []*Enclosure{
+				{URL: "https://cdn.example/1.opus", Type: "audio/opus", Length: 1000},
+				{URL: "ipfs://QmdwGqd3d2gFPGeJNLLCshdiPert45fMu84552Y4XHTy4y", Type: "audio/ogg", Length: 1000},
+			},
+		}},
+	}
+	if got := feed.Items[0].Podcast; !reflect.DeepEqual(got, wantItem) {
+		t.Errorf("Expected %+v, got %+v", wantItem, got)
+	}
+	if feed.Items[1].Podcast != nil {
+		t.Errorf("Expected no podcast metadata, got %+v", feed.Items[1].Podcast)
+	}
+}
+
+func TestPodcastNamespaceAlias(t *testing.T) {
+	const feed = `<rss version="2.0" xmlns:podcast="https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md">
+<channel><item><guid>1</guid><podcast:season>4</podcast:season></item></channel>
+</rss>`
+
+	out, err := ParseWithOptions([]byte(feed), &ParseOptions{BaseURL: "https://podcast.example/feed"})
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if p := out.Items[0].Podcast; p == nil || p.Season == nil || p.Season.Number != 4 {
+		t.Errorf("Expected season 4, got %+v", p)
+	}
+}
+
+func TestPodcastAlternateEnclosureLenient(t *testing.T) {
+	const feed = `<rss version="2.0" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel><item>
+	<guid>1</guid>
+	<podcast:alternateEnclosure type="audio/mpeg" length="1,000" bitrate="128k" height="" default="yes">
+		<podcast:source uri="https://cdn.example/1.mp3"/>
+	</podcast:alternateEnclosure>
+</item></channel>
+</rss>`
+
+	out, err := Parse([]byte(feed))
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	p := out.Items[0].Podcast
+	if p == nil || len(p.AlternateEnclosures) != 1 {
+		t.Fatalf("Expected an alternate enclosure, got %+v", p)
+	}
+	if got := p.AlternateEnclosures[0]; got.Type != "audio/mpeg" || got.Length != 0 || got.Bitrate != 0 || got.Default {
+		t.Errorf("Expected bad values to be left out, got %+v", got)
+	}
+	if len(out.Warnings) != 2 || out.Warnings[0].Code != WarnInvalidValue || out.Warnings[1].Code != WarnInvalidValue {
+		t.Errorf("Expected warnings about the length and bitrate, got %+v", out.Warnings)
+	}
+}
@@ -0,0 +1,243 @@
+package rss
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A RefreshPolicy decides how the refresh time declared by the feed
+// itself (such as RSS <ttl>) and the one declared by the HTTP response
+// (Cache-Control max-age or Expires) are combined into Feed.Refresh.
+//
+// Whatever the policy, a Retry-After header sent with a 429 or 503
+// response is always honoured, and DefaultRefreshInterval is used when
+// neither the feed nor the response declares anything.
+type RefreshPolicy int
+
+const (
+	// RefreshDefault uses DefaultRefreshPolicy. It is the zero value, so
+	// that ParseOptions left unset follow the package default.
+	RefreshDefault RefreshPolicy = iota
+
+	// RefreshLatest uses the later of the two times, so that neither
+	// the feed nor the server is polled sooner than it asks for.
+	RefreshLatest
+
+	// RefreshPreferFeed uses the feed's time if it declares one, and
+	// the HTTP time otherwise.
+	RefreshPreferFeed
+
+	// RefreshPreferHTTP uses the HTTP time if the response declares
+	// one, and the feed's time otherwise.
+	RefreshPreferHTTP
+)
+
+// DefaultRefreshPolicy is the RefreshPolicy used when fetching and
+// updating feeds.
+var DefaultRefreshPolicy = RefreshLatest
+
+// combine merges the refresh times declared by the feed and by the HTTP
+// response. Either may be zero if it was not declared, and the result is
+// zero if neither was.
+func (p RefreshPolicy) combine(feed, http time.Time) time.Time {
+	if p == RefreshDefault {
+		p = DefaultRefreshPolicy
+	}
+
+	switch {
+	case feed.IsZero():
+		return http
+	case http.IsZero():
+		return feed
+	case p == RefreshPreferFeed:
+		return feed
+	case p == RefreshPreferHTTP:
+		return http
+	case http.After(feed):
+		return http
+	default:
+		return feed
+	}
+}
+
+// ttlRefresh computes the refresh time from the RSS <ttl>, <skipHours>
+// and <skipDays> elements. It returns the zero time if ttl is zero.
+func ttlRefresh(ttl int, skipHours []int, skipDays []string) time.Time {
+	if ttl == 0 {
+		return time.Time{}
+	}
+
+	sort.Ints(skipHours)
+	next := time.Now().Add(time.Duration(ttl) * time.Minute)
+	for _, hour := range skipHours {
+		if hour == next.Hour() {
+			next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
+		}
+	}
+	trying := true
+	for trying {
+		trying = false
+		for _, day := range skipDays {
+			if strings.Title(day) == next.Weekday().String() {
+				next = next.Add(time.Duration(24-next.Hour()) * time.Hour)
+				trying = true
+				break
+			}
+		}
+	}
+
+	return next
+}
+
+// syPeriods are the durations of the Syndication module's update periods.
+// Months and years are taken to be of average length.
+var syPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 730 * time.Hour,
+	"yearly":  8766 * time.Hour,
+}
+
+// syElements are the elements of the RSS 1.0 Syndication module, which
+// RSS 2.0 feeds use too. See
+// https://web.resource.org/rss/1.0/modules/syndication/.
+type syElements struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	UpdateBase      string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateBase"`
+}
+
+// refresh computes the refresh time declared by the elements, parsing
+// updateBase with p. It returns the zero time if none are given.
+func (sy *syElements) refresh(p *Parser) time.Time {
+	period := strings.ToLower(strings.TrimSpace(sy.UpdatePeriod))
+	frequency := strings.TrimSpace(sy.UpdateFrequency)
+	if period == "" && frequency == "" {
+		return time.Time{}
+	}
+
+	n := 1
+	if frequency != "" {
+		var err error
+		if n, err = strconv.Atoi(frequency); err != nil || n <= 0 {
+			p.Warn(WarnInvalidSchedule, "updateFrequency", fmt.Sprintf("invalid update frequency %q", sy.UpdateFrequency))
+			n = 1
+		}
+	}
+	if period == "" {
+		period = "daily"
+	} else if _, ok := syPeriods[period]; !ok {
+		p.Warn(WarnInvalidSchedule, "updatePeriod", fmt.Sprintf("unknown update period %q", sy.UpdatePeriod))
+		return time.Time{}
+	}
+
+	var base time.Time
+	if sy.UpdateBase != "" {
+		t, err := p.ParseTime(sy.UpdateBase)
+		if err != nil {
+			// The module's own example gives no seconds.
+			t, err = time.Parse("2006-01-02T15:04Z07:00", strings.TrimSpace(sy.UpdateBase))
+		}
+		if err != nil {
+			p.Warn(WarnInvalidDate, "updateBase", fmt.Sprintf("cannot parse date %q", sy.UpdateBase))
+		} else {
+			base = t
+		}
+	}
+	return syRefresh(period, n, base, time.Now())
+}
+
+// syRefresh computes the first time after now that a feed publishing
+// frequency times per period, starting at base, is next updated. Without
+// a base, the feed is assumed to have just been updated.
+func syRefresh(period string, frequency int, base, now time.Time) time.Time {
+	interval := syPeriods[period] / time.Duration(frequency)
+	if base.IsZero() || interval <= 0 {
+		return now.Add(interval)
+	}
+	if base.After(now) {
+		return base
+	}
+	n := now.Sub(base)/interval + 1
+	return base.Add(n * interval)
+}
+
+// httpRefresh computes the refresh time declared by the cache headers of
+// an HTTP response. It returns the zero time if none is declared, or if
+// the response is already stale, as with max-age=0 or an Expires in the
+// past: that says nothing about when the feed will change, and a refresh
+// time of now would have the feed fetched again straight away.
+func httpRefresh(header http.Header) time.Time {
+	lifetime := httpLifetime(header)
+	if lifetime <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(lifetime)
+}
+
+// httpLifetime returns how much longer the response with the given
+// headers stays fresh, or zero or less if it does not or they do not say.
+func httpLifetime(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		maxAge, err := strconv.ParseInt(strings.Trim(directive[len("max-age="):], `"`), 10, 64)
+		if err != nil || maxAge < 0 {
+			continue
+		}
+		// The response may already have spent some of its lifetime in a cache.
+		if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+			maxAge -= age
+		}
+		return time.Duration(maxAge) * time.Second
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// Invalid dates, such as "0", mean already expired.
+			return 0
+		}
+		// Measure against the server's clock where possible, in case
+		// ours is skewed.
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			return t.Sub(date)
+		}
+		return time.Until(t)
+	}
+
+	return 0
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns the zero time if the header is
+// absent or invalid.
+func retryAfter(header http.Header) time.Time {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return time.Time{}
+		}
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// shouldRetryLater reports whether a response with the given status code
+// may carry a Retry-After header asking us to back off.
+func shouldRetryLater(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
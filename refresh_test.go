@@ -0,0 +1,231 @@
+package rss
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPRefresh(t *testing.T) {
+	now := time.Now()
+	tests := map[string]struct {
+		header http.Header
+		want   time.Duration
+	}{
+		"max-age": {
+			header: http.Header{"Cache-Control": {"public, max-age=3600"}},
+			want:   time.Hour,
+		},
+		"max-age with age": {
+			header: http.Header{"Cache-Control": {"max-age=3600"}, "Age": {"600"}},
+			want:   50 * time.Minute,
+		},
+		"max-age over expires": {
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Expires": {now.Add(time.Hour).UTC().Format(http.TimeFormat)}},
+			want:   time.Minute,
+		},
+		"expires relative to date": {
+			header: http.Header{
+				"Date":    {"Mon, 02 Jan 2006 15:04:05 GMT"},
+				"Expires": {"Mon, 02 Jan 2006 17:04:05 GMT"},
+			},
+			want: 2 * time.Hour,
+		},
+	}
+
+	for name, tt := range tests {
+		got := httpRefresh(tt.header)
+		if d := got.Sub(now) - tt.want; d < -time.Second || d > time.Second {
+			t.Errorf("%s: got refresh in %v, want %v", name, got.Sub(now), tt.want)
+		}
+	}
+
+	if got := httpRefresh(http.Header{}); !got.IsZero() {
+		t.Errorf("no headers: got %v, want zero time", got)
+	}
+
+	// Stale responses give no refresh time rather than now.
+	stale := map[string]http.Header{
+		"max-age=0":           {"Cache-Control": {"max-age=0"}},
+		"no-cache, max-age=0": {"Cache-Control": {"no-cache, max-age=0"}},
+		"age past max-age":    {"Cache-Control": {"max-age=60"}, "Age": {"120"}},
+		"invalid expires":     {"Expires": {"0"}},
+		"past expires":        {"Expires": {now.Add(-time.Hour).UTC().Format(http.TimeFormat)}},
+	}
+	for name, header := range stale {
+		if got := httpRefresh(header); !got.IsZero() {
+			t.Errorf("%s: got %v, want zero time", name, got)
+		}
+	}
+}
+
+func TestFetchStaleCacheHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache, max-age=0")
+		w.Header().Set("Expires", "0")
+		http.ServeFile(w, r, "testdata/jsonfeed_v1")
+	}))
+	defer server.Close()
+
+	feed, err := FetchByClient(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'jsonfeed_v1': %v", err)
+	}
+
+	if want := time.Now().Add(DefaultRefreshInterval - time.Minute); feed.Refresh.Before(want) {
+		t.Errorf("Expected the default refresh interval, got refresh in %v", time.Until(feed.Refresh))
+	}
+}
+
+func TestRefreshPolicy(t *testing.T) {
+	feed := time.Now().Add(time.Hour)
+	http := time.Now().Add(2 * time.Hour)
+
+	tests := map[RefreshPolicy]time.Time{
+		RefreshLatest:     http,
+		RefreshPreferFeed: feed,
+		RefreshPreferHTTP: http,
+	}
+	for policy, want := range tests {
+		if got := policy.combine(feed, http); !got.Equal(want) {
+			t.Errorf("policy %d: got %v, want %v", policy, got, want)
+		}
+		if got := policy.combine(feed, time.Time{}); !got.Equal(feed) {
+			t.Errorf("policy %d without HTTP time: got %v, want %v", policy, got, feed)
+		}
+	}
+
+	if got := RefreshLatest.combine(time.Time{}, time.Time{}); !got.IsZero() {
+		t.Errorf("Expected no refresh time, got %v", got)
+	}
+
+	var opts *ParseOptions
+	if got := opts.refresh(time.Time{}, time.Time{}); got.Before(time.Now().Add(DefaultRefreshInterval - time.Minute)) {
+		t.Errorf("Expected default refresh interval, got %v", got)
+	}
+}
+
+func TestDefaultRefreshPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=86400")
+		w.Write([]byte(`<rss version="2.0"><channel><title>Often</title><ttl>5</ttl></channel></rss>`))
+	}))
+	defer server.Close()
+
+	defer func(p RefreshPolicy) { DefaultRefreshPolicy = p }(DefaultRefreshPolicy)
+	DefaultRefreshPolicy = RefreshPreferFeed
+
+	feed, err := Fetch(server.URL)
+	if err != nil {
+		t.Fatalf("Fetching: %v", err)
+	}
+	if until := time.Until(feed.Refresh); until > 5*time.Minute {
+		t.Errorf("Expected the feed's 5m ttl to be preferred, got %v", until)
+	}
+}
+
+func TestFetchCacheControl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=86400")
+		http.ServeFile(w, r, "testdata/jsonfeed_v1")
+	}))
+	defer server.Close()
+
+	feed, err := FetchByClient(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'jsonfeed_v1': %v", err)
+	}
+
+	if want := time.Now().Add(23 * time.Hour); feed.Refresh.Before(want) {
+		t.Errorf("Expected refresh after %v, got %v", want, feed.Refresh)
+	}
+}
+
+func TestUpdateRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7200")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClient(server.Client()); err == nil {
+		t.Fatal("Expected error from 503 response")
+	}
+
+	if want := time.Now().Add(119 * time.Minute); feed.Refresh.Before(want) {
+		t.Errorf("Expected refresh after %v, got %v", want, feed.Refresh)
+	}
+}
+
+func TestSyRefresh(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 20, 0, 0, time.UTC)
+	tests := map[string]struct {
+		period    string
+		frequency int
+		base      time.Time
+		want      time.Time
+	}{
+		"hourly": {
+			period: "hourly", frequency: 1,
+			want: now.Add(time.Hour),
+		},
+		"twice daily": {
+			period: "daily", frequency: 2,
+			want: now.Add(12 * time.Hour),
+		},
+		"from base": {
+			period: "hourly", frequency: 4,
+			base: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC),
+		},
+		"base in future": {
+			period: "weekly", frequency: 1,
+			base: now.Add(time.Hour),
+			want: now.Add(time.Hour),
+		},
+	}
+
+	for name, tt := range tests {
+		if got := syRefresh(tt.period, tt.frequency, tt.base, now); !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", name, got, tt.want)
+		}
+	}
+}
+
+func TestSyndicationModule(t *testing.T) {
+	const feed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+<channel>
+<title>Hourly</title>
+<sy:updatePeriod>
+	hourly	</sy:updatePeriod>
+<sy:updateFrequency>
+	2	</sy:updateFrequency>
+<sy:updateBase>2000-01-01T00:00+00:00</sy:updateBase>
+</channel>
+</rss>`
+
+	opts := &ParseOptions{RefreshInterval: 24 * time.Hour}
+	start := time.Now()
+	out, err := ParseWithOptions([]byte(feed), opts)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if out.Refresh.Before(start) || out.Refresh.After(start.Add(31*time.Minute)) {
+		t.Errorf("Expected refresh within half an hour, got %v", out.Refresh.Sub(start))
+	}
+	if out.Refresh.Minute()%30 != 0 || out.Refresh.Second() != 0 {
+		t.Errorf("Expected refresh on the half hour, got %v", out.Refresh)
+	}
+
+	out, err = ParseWithOptions([]byte(strings.Replace(feed, "hourly", "fortnightly", 1)), opts)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	if len(out.Warnings) != 1 || out.Warnings[0].Code != WarnInvalidSchedule {
+		t.Errorf("Expected an invalid-schedule warning, got %v", out.Warnings)
+	}
+}
@@ -1,80 +1,232 @@
 package rss
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
-	"text/tabwriter"
 	"time"
 )
 
 // Parse RSS or Atom data.
 func Parse(data []byte) (*Feed, error) {
+	return ParseReader(bytes.NewReader(data))
+}
 
-	if strings.Contains(string(data), "<rss") {
-		if debug {
-			fmt.Println("[i] Parsing as RSS 2.0")
-		}
-		return parseRSS2(data)
-	} else if strings.Contains(string(data), "xmlns=\"http://purl.org/rss/1.0/\"") {
-		if debug {
-			fmt.Println("[i] Parsing as RSS 1.0")
-		}
-		return parseRSS1(data)
-	} else if strings.Contains(string(data), "https://jsonfeed.org/version/1") {
-		if debug {
-			fmt.Println("[i] Parsing as JSON Feed Version 1")
-		}
-		return parseJsonFeedV1(data)
-	} else {
-		if debug {
-			fmt.Println("[i] Parsing as Atom")
-		}
-		return parseAtom(data)
+// ParseReader parses RSS or Atom data read from r.
+func ParseReader(r io.Reader) (*Feed, error) {
+	return ParseItems(r, nil)
+}
+
+// ParseItems parses RSS or Atom data read from r, calling fn with each item
+// as soon as it has been read. The items are not stored in the returned
+// feed's Items, so feeds of any size can be processed without holding the
+// whole document. If fn returns an error, parsing stops and that error is
+// returned.
+//
+// If fn is nil, ParseItems is equivalent to ParseReader.
+func ParseItems(r io.Reader, fn func(*Item) error) (*Feed, error) {
+	out, err := parse(r, "", nil, fn)
+	if err != nil {
+		return nil, err
 	}
+
+	if out.Refresh.IsZero() {
+		out.Refresh = time.Now().Add(DefaultRefreshInterval)
+	}
+
+	return out, nil
+}
+
+// sniffLen is the number of bytes examined to detect a feed's format.
+const sniffLen = 8 << 10
+
+// parse parses r in whichever format it is in, falling back to the format
+// implied by contentType if the data itself is not recognised. opts may be
+// nil. Feed.Refresh is left zero unless the feed declares when it should
+// next be checked.
+func parse(r io.Reader, contentType string, opts *ParseOptions, fn func(*Item) error) (*Feed, error) {
+	lines := &lineIndex{r: limitReader(r, opts.limits().MaxBytes)}
+	br := bufio.NewReaderSize(lines, sniffLen)
+	if src := skipBOM(br); src != br {
+		br = bufio.NewReaderSize(src, sniffLen)
+	}
+	junk, junkLines := 0, 0
+	if opts.lenient() {
+		junk, junkLines = skipJunk(br)
+	}
+	prefix, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	f, format, ok := detectFormat(prefix, contentType)
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+
+	p := newParser(lines, format, opts, fn)
+	p.log.Debug("parsing feed")
+	if junk > 0 {
+		p.repairs.add(RepairLeadingJunk, fmt.Sprintf("%d bytes", junk), 1)
+		p.junkLines = junkLines
+	}
+	out, err := f.parse(br, p)
+	if err != nil {
+		return nil, err
+	}
+	p.resolveFeed()
+	out.Repairs = p.repairs.repairs
+	for _, r := range out.Repairs {
+		p.log.Info("repaired malformed XML", "kind", r.Kind, "detail", r.Detail, "line", r.Line, "count", r.Count)
+	}
+
+	out.Format = format
+	return out, nil
 }
 
 // A FetchFunc is a function that fetches a feed for given URL.
 type FetchFunc func(url string) (resp *http.Response, err error)
 
+// A ContextFetchFunc is a FetchFunc that can be cancelled through ctx.
+type ContextFetchFunc func(ctx context.Context, url string) (resp *http.Response, err error)
+
+// A RequestFunc is a function that performs the HTTP request for a feed.
+// Unlike a FetchFunc, it is given the whole request, so the conditional
+// request headers set by Feed.Update reach the server. The request's
+// context should be honoured.
+type RequestFunc func(req *http.Request) (resp *http.Response, err error)
+
+// requestFunc adapts f to a RequestFunc. Any request headers are dropped,
+// and the request's context is only checked before f is called.
+func (f FetchFunc) requestFunc() RequestFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return f(req.URL.String())
+	}
+}
+
+// requestFunc adapts f to a RequestFunc. Any request headers are dropped.
+func (f ContextFetchFunc) requestFunc() RequestFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return f(req.Context(), req.URL.String())
+	}
+}
+
 // DefaultFetchFunc uses http.DefaultClient to fetch a feed.
 var DefaultFetchFunc = func(url string) (resp *http.Response, err error) {
 	client := http.DefaultClient
 	return client.Get(url)
 }
 
+// DefaultContextFetchFunc uses http.DefaultClient to fetch a feed. It is
+// the ContextFetchFunc counterpart of DefaultFetchFunc, for use with
+// FetchByFuncContext.
+var DefaultContextFetchFunc = func(ctx context.Context, url string) (resp *http.Response, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// DefaultRequestFunc uses http.DefaultClient to perform the request for a
+// feed. Fetch and FetchContext use it, and set it as the feed's
+// RequestFunc, so that updates send conditional requests.
+var DefaultRequestFunc RequestFunc = func(req *http.Request) (resp *http.Response, err error) {
+	return http.DefaultClient.Do(req)
+}
+
 // Fetch downloads and parses the RSS feed at the given URL
 func Fetch(url string) (*Feed, error) {
-	return FetchByFunc(DefaultFetchFunc, url)
+	return FetchContext(context.Background(), url)
+}
+
+// FetchContext is like Fetch, but stops when ctx is done.
+func FetchContext(ctx context.Context, url string) (*Feed, error) {
+	out, err := FetchByRequestFuncContext(ctx, DefaultRequestFunc, url)
+	if err != nil {
+		return nil, err
+	}
+
+	out.FetchFunc = DefaultFetchFunc
+
+	return out, nil
 }
 
 // FetchByClient uses a http.Client to fetch a URL.
 func FetchByClient(url string, client *http.Client) (*Feed, error) {
-	fetchFunc := func(url string) (resp *http.Response, err error) {
+	return FetchByClientContext(context.Background(), url, client)
+}
+
+// FetchByClientContext is like FetchByClient, but stops when ctx is done.
+func FetchByClientContext(ctx context.Context, url string, client *http.Client) (*Feed, error) {
+	out, err := FetchByRequestFuncContext(ctx, client.Do, url)
+	if err != nil {
+		return nil, err
+	}
+
+	out.FetchFunc = func(url string) (resp *http.Response, err error) {
 		return client.Get(url)
 	}
-	return FetchByFunc(fetchFunc, url)
+
+	return out, nil
 }
 
 // FetchByFunc uses a func to fetch a URL.
 func FetchByFunc(fetchFunc FetchFunc, url string) (*Feed, error) {
-	resp, err := fetchFunc(url)
+	out, err := fetchByRequestFunc(context.Background(), fetchFunc.requestFunc(), url, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	out.FetchFunc = fetchFunc
+
+	return out, nil
+}
+
+// FetchByFuncContext is like FetchByFunc, but passes ctx to fetchFunc.
+func FetchByFuncContext(ctx context.Context, fetchFunc ContextFetchFunc, url string) (*Feed, error) {
+	return FetchByRequestFuncContext(ctx, fetchFunc.requestFunc(), url)
+}
+
+// FetchByRequestFunc uses a RequestFunc to fetch a URL. Feeds fetched this
+// way send conditional requests when they are updated.
+func FetchByRequestFunc(requestFunc RequestFunc, url string) (*Feed, error) {
+	return FetchByRequestFuncContext(context.Background(), requestFunc, url)
+}
+
+// FetchByRequestFuncContext is like FetchByRequestFunc, but the request
+// carries ctx.
+func FetchByRequestFuncContext(ctx context.Context, requestFunc RequestFunc, url string) (*Feed, error) {
+	out, err := fetchByRequestFunc(ctx, requestFunc, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := Parse(body)
+	out.RequestFunc = requestFunc
+
+	return out, nil
+}
+
+func fetchByRequestFunc(ctx context.Context, requestFunc RequestFunc, url string, opts *ParseOptions) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _, err := fetch(requestFunc, req, opts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,11 +236,165 @@ func FetchByFunc(fetchFunc FetchFunc, url string) (*Feed, error) {
 	}
 
 	out.UpdateURL = url
-	out.FetchFunc = fetchFunc
+	out.observeRedirect(out.RedirectURL, opts.logger())
 
 	return out, nil
 }
 
+// fetch performs req and parses the response body.
+//
+// If the server reports that the feed has not been modified, or the body
+// hashes to that of prev, modified is false and the body is not parsed.
+// The returned feed then only carries the response's cache validators and
+// the refresh interval prev declared. To compare it, the body is read
+// whole before it is parsed when prev has a hash; otherwise it is parsed
+// as it is read. prev and opts may be nil.
+func fetch(requestFunc RequestFunc, req *http.Request, opts *ParseOptions, prev *Feed) (out *Feed, modified bool, err error) {
+	ctx := req.Context()
+	log := opts.logger().With("url", req.URL.String())
+	opts = opts.withLogger(log)
+
+	log.DebugContext(ctx, "fetching feed")
+	resp, err := requestFunc(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		log.DebugContext(ctx, "feed not modified")
+		out = prev.unchanged()
+		out.RedirectURL = permanentRedirect(resp)
+		out.ETag = resp.Header.Get("ETag")
+		out.LastModified = resp.Header.Get("Last-Modified")
+		out.Refresh = opts.refresh(out.Refresh, httpRefresh(resp.Header))
+		return out, false, nil
+	}
+
+	// Some FetchFuncs build responses without a status code.
+	if resp.StatusCode != 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		log.DebugContext(ctx, "fetching feed failed", "status", resp.StatusCode)
+		return nil, false, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header}
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = req.URL.String()
+		if resp.Request != nil && resp.Request.URL != nil {
+			opts.BaseURL = resp.Request.URL.String()
+		}
+	}
+
+	body := limitReader(resp.Body, opts.limits().MaxBytes)
+	hasher := sha256.New()
+	if prev != nil && prev.ContentHash != "" {
+		// The body is read and hashed first, so that it is not parsed if
+		// it has not changed.
+		data, err := ioutil.ReadAll(io.TeeReader(body, hasher))
+		if err != nil {
+			return nil, false, err
+		}
+		if hex.EncodeToString(hasher.Sum(nil)) == prev.ContentHash {
+			log.DebugContext(ctx, "feed body unchanged")
+			out = prev.unchanged()
+		} else {
+			out, err = parse(bytes.NewReader(data), resp.Header.Get("Content-Type"), opts, nil)
+			if err != nil {
+				return nil, false, err
+			}
+			modified = true
+		}
+	} else {
+		// With nothing to compare against, the body is hashed as it is
+		// parsed, so it is never held whole.
+		tee := io.TeeReader(body, hasher)
+		out, err = parse(tee, resp.Header.Get("Content-Type"), opts, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		// Anything after the end of the document counts towards the hash.
+		if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+			return nil, false, err
+		}
+		modified = true
+	}
+	if modified && !out.Refresh.IsZero() {
+		out.FeedInterval = time.Until(out.Refresh).Round(time.Second)
+	}
+
+	out.Refresh = opts.refresh(out.Refresh, httpRefresh(resp.Header))
+	out.RedirectURL = permanentRedirect(resp)
+
+	out.ETag = resp.Header.Get("ETag")
+	out.LastModified = resp.Header.Get("Last-Modified")
+	out.ContentHash = hex.EncodeToString(hasher.Sum(nil))
+
+	return out, modified, nil
+}
+
+// unchanged returns a feed carrying the refresh interval declared by f,
+// which may be nil, for a response that has not changed since f.
+func (f *Feed) unchanged() *Feed {
+	out := new(Feed)
+	if f != nil && f.FeedInterval > 0 {
+		out.FeedInterval = f.FeedInterval
+		out.Refresh = time.Now().Add(f.FeedInterval)
+	}
+	return out
+}
+
+// permanentRedirect returns the URL that the request for resp was
+// permanently redirected to, or "" if it was not. Only the unbroken chain
+// of 301 and 308 redirects from the original request is followed.
+func permanentRedirect(resp *http.Response) string {
+	var chain []*http.Request
+	for req := resp.Request; req != nil; {
+		chain = append(chain, req)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+
+	target := ""
+	for i := len(chain) - 2; i >= 0; i-- {
+		switch chain[i].Response.StatusCode {
+		case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+			target = chain[i].URL.String()
+		default:
+			return target
+		}
+	}
+
+	return target
+}
+
+// observeRedirect records that fetching f.UpdateURL was permanently
+// redirected to target, or not redirected if target is "". Once the same
+// redirect has been seen PermanentRedirectThreshold times in a row,
+// UpdateURL is changed to target.
+func (f *Feed) observeRedirect(target string, log *slog.Logger) {
+	switch target {
+	case "", f.UpdateURL:
+		f.RedirectURL = ""
+		f.RedirectCount = 0
+		return
+	case f.RedirectURL:
+		f.RedirectCount++
+	default:
+		f.RedirectURL = target
+		f.RedirectCount = 1
+	}
+
+	if PermanentRedirectThreshold > 0 && f.RedirectCount >= uint32(PermanentRedirectThreshold) {
+		log.Info("feed moved permanently", "url", f.UpdateURL, "target", target)
+		f.UpdateURL = target
+		f.RedirectURL = ""
+		f.RedirectCount = 0
+	}
+}
+
 // Feed is the top-level structure.
 type Feed struct {
 	Nickname    string              `json:"nickname"` // This is not set by the package, but could be helpful.
@@ -99,32 +405,85 @@ type Feed struct {
 	Link        string              `json:"link"`      // Link to the creator's website.
 	UpdateURL   string              `json:"updateurl"` // URL of the feed itself.
 	Image       *Image              `json:"image"`     // Feed icon.
+	Favicon     string              `json:"favicon"`   // URL of a small icon for the feed, as given by JSON Feed.
 	Categories  []string            `json:"categories"`
 	Items       []*Item             `json:"items"`
 	ItemMap     map[string]struct{} `json:"itemmap"` // Used in checking whether an item has been seen before.
 	Refresh     time.Time           `json:"refresh"` // Earliest time this feed should next be checked.
 	Unread      uint32              `json:"unread"`  // Number of unread items. Used by aggregators.
-	FetchFunc   FetchFunc           `json:"-"`
+	Format      Format              `json:"format"`  // Format the feed was parsed from.
+	Rights      string              `json:"rights"`  // Copyright notice.
+	Publisher   string              `json:"publisher"`
+
+	// Authors and Contributors of the feed. Author holds the first
+	// author as text.
+	Authors      []*Person `json:"authors"`
+	Contributors []*Person `json:"contributors"`
+
+	// HTTP cache validators from the last response, used to make
+	// conditional requests. ContentHash is the SHA-256 of the last body,
+	// used to skip parsing when the server sends no validators.
+	ETag         string `json:"etag"`
+	LastModified string `json:"lastmodified"`
+	ContentHash  string `json:"contenthash"`
+
+	// FeedInterval is how long the feed itself last asked to be left
+	// before it is checked again, such as by RSS <ttl>, or zero. It is
+	// used for Refresh when an update finds the feed unchanged.
+	FeedInterval time.Duration `json:"feedinterval"`
+
+	// Gone is set when the server answers 410 Gone. Update no longer
+	// fetches the feed once it is set.
+	Gone bool `json:"gone"`
+
+	// Expired is set when a JSON Feed says it will not be updated again.
+	// Update no longer fetches the feed once it is set.
+	Expired bool `json:"expired"`
+
+	// RedirectURL is the target of a permanent redirect that has been
+	// seen RedirectCount times in a row, but not yet often enough to
+	// replace UpdateURL.
+	RedirectURL   string `json:"redirecturl"`
+	RedirectCount uint32 `json:"redirectcount"`
+
+	// SelfURL is the URL the feed declares for itself, such as an Atom
+	// rel="self" link. See SelfLinkMismatch.
+	SelfURL string `json:"selfurl"`
+
+	// Links are the feed's typed links, as given by Atom links, including
+	// atom:link in RSS.
+	Links []*Link `json:"links"`
+
+	// Hubs are where to subscribe to updates of the feed, as given by JSON
+	// Feed or rel="hub" links. NextURL is the next page of a paginated
+	// feed, as given by JSON Feed or a rel="next" link.
+	Hubs    []*Hub `json:"hubs"`
+	NextURL string `json:"nexturl"`
+
+	// ITunes is the podcast metadata given by the iTunes namespace, or
+	// nil if there is none.
+	ITunes *ITunesFeed `json:"itunes"`
+
+	// Podcast is the metadata given by the Podcasting 2.0 namespace, or
+	// nil if there is none.
+	Podcast *PodcastFeed `json:"podcast"`
+
+	// Repairs lists the problems in the feed's XML that were worked
+	// around when it was last parsed with ParseOptions.Lenient.
+	Repairs []Repair `json:"repairs"`
+
+	// Warnings lists the problems found when the feed was last parsed
+	// that did not stop it being parsed, such as dropped items.
+	Warnings []Warning `json:"warnings"`
+
+	FetchFunc   FetchFunc   `json:"-"`
+	RequestFunc RequestFunc `json:"-"`
+
+	// Options used to parse the feed when it is updated. If nil, the
+	// package defaults are used.
+	Options *ParseOptions `json:"-"`
 }
 
-type refreshError string
-
-var _ net.Error = refreshError("")
-
-func (r refreshError) Error() string {
-	return string(r)
-}
-
-func (r refreshError) Timeout() bool {
-	return false
-}
-
-func (r refreshError) Temporary() bool {
-	return true
-}
-
-var errUpdateNotReady refreshError = "not ready to update: too soon to refresh"
-
 // DefaultRefreshInterval is the minimum
 // wait until the next refresh, provided
 // the feed does not provide its own
@@ -136,23 +495,88 @@ var errUpdateNotReady refreshError = "not ready to update: too soon to refresh"
 // the feed hosts.
 //
 // The default value is 12 hours.
-//
 var DefaultRefreshInterval = 12 * time.Hour
 
+// DATE is a constant date string.
+const DATE = "15:04:05 MST 02/01/2006"
+
+// PermanentRedirectThreshold is the number of
+// consecutive fetches that must be permanently
+// redirected to the same URL before the feed's
+// UpdateURL is changed to it. Requiring more
+// than one guards against misconfigured servers.
+//
+// Setting it to zero disables updating
+// UpdateURL.
+var PermanentRedirectThreshold = 3
+
 // Update fetches any new items and updates f.
+//
+// It uses f.RequestFunc if it is set, as it is by Fetch, and otherwise
+// f.FetchFunc or DefaultFetchFunc. Only a RequestFunc can make conditional
+// requests.
 func (f *Feed) Update() error {
+	return f.UpdateContext(context.Background())
+}
+
+// UpdateContext is like Update, but stops when ctx is done. A FetchFunc
+// set on f cannot be interrupted once it has been called.
+func (f *Feed) UpdateContext(ctx context.Context) error {
+	if f.RequestFunc != nil {
+		return f.UpdateByRequestFuncContext(ctx, f.RequestFunc)
+	}
 	if f.FetchFunc == nil {
 		f.FetchFunc = DefaultFetchFunc
 	}
-	return f.UpdateByFunc(f.FetchFunc)
+	return f.UpdateByRequestFuncContext(ctx, f.FetchFunc.requestFunc())
 }
 
 // UpdateByFunc uses a func to update f.
+//
+// A FetchFunc cannot send request headers, so no conditional request is
+// made. An unchanged body is still detected by its hash and not parsed.
 func (f *Feed) UpdateByFunc(fetchFunc FetchFunc) error {
+	return f.UpdateByRequestFunc(fetchFunc.requestFunc())
+}
+
+// UpdateByFuncContext is like UpdateByFunc, but passes ctx to fetchFunc.
+func (f *Feed) UpdateByFuncContext(ctx context.Context, fetchFunc ContextFetchFunc) error {
+	return f.UpdateByRequestFuncContext(ctx, fetchFunc.requestFunc())
+}
+
+// UpdateByClient uses a http.Client to update f.
+func (f *Feed) UpdateByClient(client *http.Client) error {
+	return f.UpdateByRequestFunc(client.Do)
+}
+
+// UpdateByClientContext is like UpdateByClient, but stops when ctx is done.
+func (f *Feed) UpdateByClientContext(ctx context.Context, client *http.Client) error {
+	return f.UpdateByRequestFuncContext(ctx, client.Do)
+}
+
+// UpdateByRequestFunc uses a RequestFunc to update f.
+//
+// The request carries If-None-Match and If-Modified-Since headers from the
+// previous response, so servers can answer 304 Not Modified instead of
+// sending the feed again.
+func (f *Feed) UpdateByRequestFunc(requestFunc RequestFunc) error {
+	return f.UpdateByRequestFuncContext(context.Background(), requestFunc)
+}
+
+// UpdateByRequestFuncContext is like UpdateByRequestFunc, but the request
+// carries ctx.
+func (f *Feed) UpdateByRequestFuncContext(ctx context.Context, requestFunc RequestFunc) error {
+	if f.Gone {
+		return ErrGone
+	}
+
+	if f.Expired {
+		return ErrExpired
+	}
 
 	// Check that we don't update too often.
 	if f.Refresh.After(time.Now()) {
-		return errUpdateNotReady
+		return &NotReadyError{Refresh: f.Refresh}
 	}
 
 	if f.UpdateURL == "" {
@@ -168,14 +592,72 @@ func (f *Feed) UpdateByFunc(fetchFunc FetchFunc) error {
 		}
 	}
 
-	update, err := FetchByFunc(fetchFunc, f.UpdateURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.UpdateURL, nil)
+	if err != nil {
+		return err
+	}
+	if f.ETag != "" {
+		req.Header.Set("If-None-Match", f.ETag)
+	}
+	if f.LastModified != "" {
+		req.Header.Set("If-Modified-Since", f.LastModified)
+	}
+
+	log := f.Options.logger()
+	update, modified, err := fetch(requestFunc, req, f.Options, f)
 	if err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && shouldRetryLater(httpErr.StatusCode) {
+			if next := retryAfter(httpErr.Header); next.After(f.Refresh) {
+				log.InfoContext(ctx, "server asked to retry later", "url", f.UpdateURL, "refresh", next)
+				f.Refresh = next
+			}
+		}
+		if errors.Is(err, ErrGone) {
+			log.WarnContext(ctx, "feed is gone", "url", f.UpdateURL)
+			f.Gone = true
+		}
 		return err
 	}
 
+	f.observeRedirect(update.RedirectURL, log)
+
+	// A 304 may omit validators that are still current.
+	if update.ETag != "" || update.LastModified != "" {
+		f.ETag = update.ETag
+		f.LastModified = update.LastModified
+	}
+	if update.ContentHash != "" {
+		f.ContentHash = update.ContentHash
+	}
+
 	f.Refresh = update.Refresh
+	f.FeedInterval = update.FeedInterval
+
+	if !modified {
+		return nil
+	}
+
 	f.Title = update.Title
 	f.Description = update.Description
+	f.Rights = update.Rights
+	f.Language = update.Language
+	f.Publisher = update.Publisher
+	f.Format = update.Format
+	f.SelfURL = update.SelfURL
+	f.Links = update.Links
+	f.Hubs = update.Hubs
+	f.NextURL = update.NextURL
+	f.Image = update.Image
+	f.Favicon = update.Favicon
+	f.Expired = update.Expired
+	f.ITunes = update.ITunes
+	f.Podcast = update.Podcast
+	f.Author = update.Author
+	f.Authors = update.Authors
+	f.Contributors = update.Contributors
+	f.Repairs = update.Repairs
+	f.Warnings = update.Warnings
 
 	for _, item := range update.Items {
 		if _, ok := f.ItemMap[item.ID]; !ok {
@@ -188,39 +670,47 @@ func (f *Feed) UpdateByFunc(fetchFunc FetchFunc) error {
 	return nil
 }
 
+// SelfLinkMismatch reports whether the feed declares a URL for itself that
+// differs from the URL it is fetched from. This often means the feed has
+// moved without redirecting, or is being served from a mirror.
+func (f *Feed) SelfLinkMismatch() bool {
+	if f.SelfURL == "" || f.UpdateURL == "" {
+		return false
+	}
+	return normalizeURL(f.SelfURL) != normalizeURL(f.UpdateURL)
+}
+
+// normalizeURL puts u in a form where equivalent URLs compare equal.
+func normalizeURL(u string) string {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return u
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if port := parsed.Port(); (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		parsed.Host = parsed.Hostname()
+	}
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
 func (f *Feed) String() string {
-	buf := new(bytes.Buffer)
-	if debug {
-		w := tabwriter.NewWriter(buf, 0, 8, 0, '\t', tabwriter.StripEscape)
-		fmt.Fprintf(w, "Feed {\n")
-		fmt.Fprintf(w, "\xff\t\xffNickname:\t%q\n", f.Nickname)
-		fmt.Fprintf(w, "\xff\t\xffTitle:\t%q\n", f.Title)
-		fmt.Fprintf(w, "\xff\t\xffDescription:\t%q\n", f.Description)
-		fmt.Fprintf(w, "\xff\t\xffLink:\t%q\n", f.Link)
-		fmt.Fprintf(w, "\xff\t\xffUpdateURL:\t%q\n", f.UpdateURL)
-		fmt.Fprintf(w, "\xff\t\xffImage:\t%q (%s)\n", f.Image.Title, f.Image.URL)
-		fmt.Fprintf(w, "\xff\t\xffRefresh:\t%s\n", f.Refresh.Format(DATE))
-		fmt.Fprintf(w, "\xff\t\xffUnread:\t%d\n", f.Unread)
-		fmt.Fprintf(w, "\xff\t\xffItems:\t(%d) {\n", len(f.Items))
-		for _, item := range f.Items {
-			fmt.Fprintf(w, "%s\n", item.Format(2))
-		}
-		fmt.Fprintf(w, "\xff\t\xff}\n}\n")
-		w.Flush()
-	} else {
-		w := buf
-		fmt.Fprintf(w, "Feed %q\n", f.Title)
-		fmt.Fprintf(w, "\t%q\n", f.Description)
-		fmt.Fprintf(w, "\t%q\n", f.Link)
-		fmt.Fprintf(w, "\t%s\n", f.Image)
-		fmt.Fprintf(w, "\tRefresh at %s\n", f.Refresh.Format(DATE))
-		fmt.Fprintf(w, "\tUnread: %d\n", f.Unread)
-		fmt.Fprintf(w, "\tItems:\n")
-		for _, item := range f.Items {
-			fmt.Fprintf(w, "\t%s\n", item.Format(2))
-		}
+	w := new(bytes.Buffer)
+	fmt.Fprintf(w, "Feed %q\n", f.Title)
+	fmt.Fprintf(w, "\t%q\n", f.Description)
+	fmt.Fprintf(w, "\t%q\n", f.Link)
+	fmt.Fprintf(w, "\t%s\n", f.Image)
+	fmt.Fprintf(w, "\tRefresh at %s\n", f.Refresh.Format(DATE))
+	fmt.Fprintf(w, "\tUnread: %d\n", f.Unread)
+	fmt.Fprintf(w, "\tItems:\n")
+	for _, item := range f.Items {
+		fmt.Fprintf(w, "\t%s\n", item.Format(2))
 	}
-	return buf.String()
+	return w.String()
 }
 
 // Item represents a single story.
@@ -232,10 +722,39 @@ type Item struct {
 	Link       string    `json:"link"`
 	Date       time.Time `json:"date"`
 	Image      *Image    `json:"image"`
+	Banner     *Image    `json:"banner"` // A wide image, as given by JSON Feed.
 	DateValid  bool
 	ID         string       `json:"id"`
 	Enclosures []*Enclosure `json:"enclosures"`
 	Read       bool         `json:"read"`
+
+	Authors      []*Person `json:"authors"`
+	Contributors []*Person `json:"contributors"`
+
+	// Links are the item's typed links, as given by Atom links, including
+	// atom:link in RSS.
+	Links []*Link `json:"links"`
+
+	// ITunes is the episode metadata given by the iTunes namespace, or
+	// nil if there is none.
+	ITunes *ITunesItem `json:"itunes"`
+
+	// Podcast is the episode metadata given by the Podcasting 2.0
+	// namespace, or nil if there is none.
+	Podcast *PodcastItem `json:"podcast"`
+
+	// Media is the metadata of the item's media given by Media RSS, or nil
+	// if there is none.
+	Media *Media `json:"media"`
+
+	// The types of Summary and Content, which are ContentTypeText,
+	// ContentTypeHTML, or a MIME type. ContentURL is where the content
+	// can be found, if it is not included in the feed.
+	SummaryType string `json:"summarytype"`
+	ContentType string `json:"contenttype"`
+	ContentURL  string `json:"contenturl"`
+	Rights      string `json:"rights"`   // Copyright notice.
+	Language    string `json:"language"` // Language of the item, if given.
 }
 
 func (i *Item) String() string {
@@ -244,48 +763,72 @@ func (i *Item) String() string {
 
 // Format formats an item using tabs.
 func (i *Item) Format(indent int) string {
-	buf := new(bytes.Buffer)
+	w := new(bytes.Buffer)
 	single := strings.Repeat("\t", indent)
 	double := single + "\t"
-	if debug {
-		w := tabwriter.NewWriter(buf, 0, 8, 0, '\t', tabwriter.StripEscape)
-		fmt.Fprintf(w, "\xff%s\xffItem {\n", single)
-		fmt.Fprintf(w, "\xff%s\xffTitle:\t%q\n", double, i.Title)
-		fmt.Fprintf(w, "\xff%s\xffSummary:\t%q\n", double, i.Summary)
-		fmt.Fprintf(w, "\xff%s\xffCategories:\t%q\n", double, i.Categories)
-		fmt.Fprintf(w, "\xff%s\xffLink:\t%s\n", double, i.Link)
-		fmt.Fprintf(w, "\xff%s\xffDate:\t%s\n", double, i.Date.Format(DATE))
-		fmt.Fprintf(w, "\xff%s\xffID:\t%s\n", double, i.ID)
-		fmt.Fprintf(w, "\xff%s\xffRead:\t%v\n", double, i.Read)
-		fmt.Fprintf(w, "\xff%s\xffContent:\t%q\n", double, i.Content)
-		fmt.Fprintf(w, "\xff%s\xff}\n", single)
-		w.Flush()
-	} else {
-		w := buf
-		fmt.Fprintf(w, "%sItem %q\n", single, i.Title)
-		fmt.Fprintf(w, "%s%q\n", double, i.Link)
-		fmt.Fprintf(w, "%s%s\n", double, i.Date.Format(DATE))
-		fmt.Fprintf(w, "%s%q\n", double, i.ID)
-		fmt.Fprintf(w, "%sRead: %v\n", double, i.Read)
-		fmt.Fprintf(w, "%s%q\n", double, i.Content)
-	}
-	return buf.String()
+	fmt.Fprintf(w, "%sItem %q\n", single, i.Title)
+	fmt.Fprintf(w, "%s%q\n", double, i.Link)
+	fmt.Fprintf(w, "%s%s\n", double, i.Date.Format(DATE))
+	fmt.Fprintf(w, "%s%q\n", double, i.ID)
+	fmt.Fprintf(w, "%sRead: %v\n", double, i.Read)
+	fmt.Fprintf(w, "%s%q\n", double, i.Content)
+	return w.String()
 }
 
-// Enclosure maps an enclosure.
+// Enclosure maps an enclosure: a media object attached to an item, such as
+// the audio of a podcast episode. Fields the feed does not give are zero.
 type Enclosure struct {
-	URL    string `json:"url"`
-	Type   string `json:"type"`
-	Length uint   `json:"length"`
+	URL   string `json:"url"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+
+	// Length is the size of the media in bytes, and Duration its running
+	// time.
+	Length   uint          `json:"length"`
+	Duration time.Duration `json:"duration"`
+
+	// TypeGuessed is set where the feed gives no type, and Type has instead
+	// been guessed from the extension of the URL.
+	TypeGuessed bool `json:"typeguessed"`
+
+	// Medium is the kind of media, such as "image", "audio" or "video".
+	// Where Media RSS gives several renditions of the same media,
+	// IsDefault marks the one to use by default. See Item.BestRendition.
+	Medium    string `json:"medium"`
+	IsDefault bool   `json:"isdefault"`
+	Bitrate   uint   `json:"bitrate"` // In kilobits per second.
+	Width     uint   `json:"width"`
+	Height    uint   `json:"height"`
 }
 
-// Get uses http.Get to fetch an enclosure.
+// Get uses http.DefaultClient to fetch an enclosure.
 func (e *Enclosure) Get() (io.ReadCloser, error) {
+	return e.GetContext(context.Background())
+}
+
+// GetContext is like Get, but stops when ctx is done.
+func (e *Enclosure) GetContext(ctx context.Context) (io.ReadCloser, error) {
+	return e.GetByClientContext(ctx, http.DefaultClient)
+}
+
+// GetByClient uses a http.Client, such as one from NewSafeClient, to fetch
+// an enclosure.
+func (e *Enclosure) GetByClient(client *http.Client) (io.ReadCloser, error) {
+	return e.GetByClientContext(context.Background(), client)
+}
+
+// GetByClientContext is like GetByClient, but stops when ctx is done.
+func (e *Enclosure) GetByClientContext(ctx context.Context, client *http.Client) (io.ReadCloser, error) {
 	if e == nil || e.URL == "" {
 		return nil, errors.New("No enclosure")
 	}
 
-	res, err := http.Get(e.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -293,6 +836,23 @@ func (e *Enclosure) Get() (io.ReadCloser, error) {
 	return res.Body, nil
 }
 
+// Link is a typed link, as given by an Atom link. Rel is the relation of
+// the linked resource, such as "alternate", "self" or "enclosure".
+type Link struct {
+	Href     string `json:"href"`
+	Rel      string `json:"rel"`
+	Type     string `json:"type"`
+	HrefLang string `json:"hreflang"`
+	Title    string `json:"title"`
+	Length   uint   `json:"length"`
+}
+
+// Hub is a hub through which updates of a feed are pushed to subscribers.
+type Hub struct {
+	Type string `json:"type"` // Such as "WebSub" or "rssCloud".
+	URL  string `json:"url"`
+}
+
 // Image maps an image.
 type Image struct {
 	Title  string `json:"title"`
@@ -302,13 +862,34 @@ type Image struct {
 	Width  uint32 `json:"width"`
 }
 
-// Get uses http.Get to fetch an image.
+// Get uses http.DefaultClient to fetch an image.
 func (i *Image) Get() (io.ReadCloser, error) {
+	return i.GetContext(context.Background())
+}
+
+// GetContext is like Get, but stops when ctx is done.
+func (i *Image) GetContext(ctx context.Context) (io.ReadCloser, error) {
+	return i.GetByClientContext(ctx, http.DefaultClient)
+}
+
+// GetByClient uses a http.Client, such as one from NewSafeClient, to fetch
+// an image.
+func (i *Image) GetByClient(client *http.Client) (io.ReadCloser, error) {
+	return i.GetByClientContext(context.Background(), client)
+}
+
+// GetByClientContext is like GetByClient, but stops when ctx is done.
+func (i *Image) GetByClientContext(ctx context.Context, client *http.Client) (io.ReadCloser, error) {
 	if i == nil || i.URL == "" {
 		return nil, errors.New("No image")
 	}
 
-	res, err := http.Get(i.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -1,172 +1,147 @@
 package rss
 
 import (
-	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"sort"
+	"io"
 	"strings"
-	"time"
 )
 
-func parseRSS1(data []byte) (*Feed, error) {
-	warnings := false
+func parseRSS1(r io.Reader, p *Parser) (*Feed, error) {
+	var err error
 	feed := rss1_0Feed{}
-	p := xml.NewDecoder(bytes.NewReader(data))
-	p.CharsetReader = charsetReader
-	err := p.Decode(&feed)
-	if err != nil {
-		return nil, err
-	}
-	if feed.Channel == nil {
-		return nil, fmt.Errorf("no channel found in %q", string(data))
-	}
-
-	channel := feed.Channel
-
-	out := new(Feed)
-	out.Title = channel.Title
-	out.Description = channel.Description
-	out.Link = channel.Link
-	out.Image = channel.Image.Image()
-	if channel.MinsToLive != 0 {
-		sort.Ints(channel.SkipHours)
-		next := time.Now().Add(time.Duration(channel.MinsToLive) * time.Minute)
-		for _, hour := range channel.SkipHours {
-			if hour == next.Hour() {
-				next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
-			}
+	feed.Items = func(d *xml.Decoder, start xml.StartElement) error {
+		item := rss1_0Item{}
+		if err := d.DecodeElement(&item, &start); err != nil {
+			return err
 		}
-		trying := true
-		for trying {
-			trying = false
-			for _, day := range channel.SkipDays {
-				if strings.Title(day) == next.Weekday().String() {
-					next = next.Add(time.Duration(24-next.Hour()) * time.Hour)
-					trying = true
-					break
-				}
-			}
-		}
-
-		out.Refresh = next
-	}
-
-	if out.Refresh.IsZero() {
-		out.Refresh = time.Now().Add(DefaultRefreshInterval)
-	}
-
-	out.Items = make([]*Item, 0, len(feed.Items))
-	out.ItemMap = make(map[string]struct{})
-
-	// Process items.
-	for _, item := range feed.Items {
 
+		if item.ID == "" {
+			item.ID = strings.TrimSpace(item.Identifier)
+		}
 		if item.ID == "" {
 			if item.Link == "" {
-				if debug {
-					fmt.Printf("[w] Item %q has no ID or link and will be ignored.\n", item.Title)
-					fmt.Printf("[w] %#v\n", item)
-				}
-				warnings = true
-				continue
+				p.Drop(WarnMissingID, "link", fmt.Sprintf("item %q has no ID or link", item.Title))
+				return nil
 			}
 			item.ID = item.Link
 		}
 
-		// Skip items already known.
-		if _, ok := out.ItemMap[item.ID]; ok {
-			continue
-		}
-
 		next := new(Item)
 		next.Title = item.Title
-		next.Summary = item.Description
-		next.Content = item.Content
+		next.Summary = string(item.Description)
+		next.Content = string(item.Content)
+		// Descriptions are commonly HTML, though RSS does not say so.
+		next.SummaryType = contentType(next.Summary, ContentTypeHTML)
+		next.ContentType = contentType(next.Content, ContentTypeHTML)
 		next.Link = item.Link
-		if item.Date != "" {
-			next.Date, err = parseTime(item.Date)
-			if err == nil {
-				next.DateValid = true
-			}
-		} else if item.PubDate != "" {
-			next.Date, err = parseTime(item.PubDate)
-			if err == nil {
-				next.DateValid = true
-			}
-		}
+		next.Links = atomLinks(p, p.items, item.ID, item.AtomLinks)
+		item.dcElements.item(next)
+		p.itemDate(next, "date", item.Date)
+		p.itemDate(next, "pubDate", item.PubDate)
 		next.ID = item.ID
 		if len(item.Enclosures) > 0 {
 			next.Enclosures = make([]*Enclosure, len(item.Enclosures))
 			for i := range item.Enclosures {
-				next.Enclosures[i] = item.Enclosures[i].Enclosure()
+				next.Enclosures[i] = item.Enclosures[i].Enclosure(p, item.ID)
 			}
 		}
 		next.Read = false
 
-		out.Items = append(out.Items, next)
-		out.ItemMap[next.ID] = struct{}{}
-		out.Unread++
+		// Items are resolved against the link of the channel before them.
+		if feed.Channel != nil {
+			p.itemBase = feed.Channel.Link
+		}
+		return p.Emit(next)
 	}
 
-	if warnings && debug {
-		fmt.Printf("[i] Encountered warnings:\n%s\n", data)
+	err = p.decodeXML(r, &feed, nsRSS, rssSpaces)
+	if err != nil {
+		return nil, err
+	}
+	if feed.Channel == nil {
+		return nil, &ParseError{Format: p.format.String(), Err: errors.New("no channel found")}
 	}
 
+	channel := feed.Channel
+
+	out := p.out
+	out.Title = channel.Title
+	out.Description = string(channel.Description)
+	channel.dcElements.feed(out)
+	out.Author = authorName(out.Authors)
+	out.Link = channel.Link
+	out.Links = atomLinks(p, -1, "", channel.AtomLinks)
+	out.SelfURL = linkHref(out.Links, "self")
+	out.Hubs = hubLinks(out.Links)
+	out.NextURL = linkHref(out.Links, "next")
+	out.Image = channel.Image.Image()
+	// Where the feed declares both, it is not polled sooner than either
+	// asks.
+	out.Refresh = RefreshLatest.combine(
+		ttlRefresh(channel.MinsToLive, channel.SkipHours, channel.SkipDays),
+		channel.syElements.refresh(p),
+	)
+
 	return out, nil
 }
 
 type rss1_0Feed struct {
 	XMLName xml.Name       `xml:"RDF"`
-	Channel *rss1_0Channel `xml:"channel"`
-	Items   []rss1_0Item   `xml:"item"`
+	Channel *rss1_0Channel `xml:"urn:rss channel"`
+	Items   elementFunc    `xml:"urn:rss item"`
 }
 
 type rss1_0Channel struct {
 	XMLName     xml.Name    `xml:"channel"`
-	Title       string      `xml:"title"`
-	Description string      `xml:"description"`
-	Link        string      `xml:"link"`
-	Image       rss1_0Image `xml:"image"`
-	MinsToLive  int         `xml:"ttl"`
-	SkipHours   []int       `xml:"skipHours>hour"`
-	SkipDays    []string    `xml:"skipDays>day"`
+	Title       string      `xml:"urn:rss title"`
+	Description rssText     `xml:"urn:rss description"`
+	Link        string      `xml:"urn:rss link"`
+	AtomLinks   []atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Image       rss1_0Image `xml:"urn:rss image"`
+	MinsToLive  int         `xml:"urn:rss ttl"`
+	SkipHours   []int       `xml:"urn:rss skipHours>hour"`
+	SkipDays    []string    `xml:"urn:rss skipDays>day"`
+	dcElements
+	syElements
 }
 
 type rss1_0Item struct {
-	XMLName     xml.Name `xml:"item"`
-	Title       string   `xml:"title"`
-	Description string   `xml:"description"`
-	Content     string   `xml:"encoded"`
-	Link        string   `xml:"link"`
-	PubDate     string   `xml:"pubDate"`
-	Date        string   `xml:"date"`
+	XMLName     xml.Name   `xml:"item"`
+	Title       string     `xml:"urn:rss title"`
+	Description rssText    `xml:"urn:rss description"`
+	Content     rssText    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Link        string     `xml:"urn:rss link"`
+	AtomLinks   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	PubDate     string     `xml:"urn:rss pubDate"`
 	DateValid   bool
-	ID          string            `xml:"guid"`
-	Enclosures  []rss1_0Enclosure `xml:"enclosure"`
+	ID          string            `xml:"urn:rss guid"`
+	Enclosures  []rss1_0Enclosure `xml:"http://purl.oclc.org/net/rss_2.0/enc# enclosure"`
+	dcElements
 }
 
 type rss1_0Enclosure struct {
 	XMLName xml.Name `xml:"enclosure"`
 	URL     string   `xml:"resource,attr"`
 	Type    string   `xml:"type,attr"`
-	Length  uint     `xml:"length,attr"`
+	Length  string   `xml:"length,attr"` // Often malformed, so parsed leniently.
 }
 
-func (r *rss1_0Enclosure) Enclosure() *Enclosure {
+func (r *rss1_0Enclosure) Enclosure(p *Parser, id string) *Enclosure {
 	out := new(Enclosure)
-	out.URL = r.URL
-	out.Type = r.Type
-	out.Length = r.Length
+	out.URL = strings.TrimSpace(r.URL)
+	out.Type = strings.TrimSpace(r.Type)
+	out.Length = uint(p.parseInt(p.items, id, "enclosure", r.Length))
 	return out
 }
 
 type rss1_0Image struct {
 	XMLName xml.Name `xml:"image"`
-	Title   string   `xml:"title"`
-	URL     string   `xml:"url"`
-	Height  int      `xml:"height"`
-	Width   int      `xml:"width"`
+	Title   string   `xml:"urn:rss title"`
+	URL     string   `xml:"urn:rss url"`
+	Height  int      `xml:"urn:rss height"`
+	Width   int      `xml:"urn:rss width"`
 }
 
 func (i *rss1_0Image) Image() *Image {
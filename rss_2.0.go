@@ -1,137 +1,113 @@
 package rss
 
 import (
-	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"sort"
+	"io"
 	"strings"
-	"time"
 )
 
-func parseRSS2(data []byte) (*Feed, error) {
-	warnings := false
-	feed := rss20Feed{}
-	p := xml.NewDecoder(bytes.NewReader(data))
-	p.CharsetReader = charsetReader
-	err := p.Decode(&feed)
-	if err != nil {
-		return nil, err
-	}
-	if feed.Channel == nil {
-		return nil, fmt.Errorf("no channel found in %q", string(data))
-	}
-
-	channel := feed.Channel
-
-	out := new(Feed)
-	out.Title = channel.Title
-	out.Language = channel.Language
-	out.Author = channel.Author
-	out.Description = channel.Description
-	out.Categories = channel.Categories.toArray()
-	out.Link = extractLink(channel.Link)
-	out.Image = channel.Image.Image()
-	if channel.MinsToLive != 0 {
-		sort.Ints(channel.SkipHours)
-		next := time.Now().Add(time.Duration(channel.MinsToLive) * time.Minute)
-		for _, hour := range channel.SkipHours {
-			if hour == next.Hour() {
-				next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
-			}
-		}
-		trying := true
-		for trying {
-			trying = false
-			for _, day := range channel.SkipDays {
-				if strings.Title(day) == next.Weekday().String() {
-					next = next.Add(time.Duration(24-next.Hour()) * time.Hour)
-					trying = true
-					break
-				}
-			}
+func parseRSS2(r io.Reader, p *Parser) (*Feed, error) {
+	var err error
+	feed := rss20Feed{Channel: new(rss20Channel)}
+	feed.Channel.Items = func(d *xml.Decoder, start xml.StartElement) error {
+		item := rss20item{}
+		if err := d.DecodeElement(&item, &start); err != nil {
+			return err
 		}
 
-		out.Refresh = next
-	}
-
-	if out.Refresh.IsZero() {
-		out.Refresh = time.Now().Add(DefaultRefreshInterval)
-	}
-
-	out.Items = make([]*Item, 0, len(channel.Items))
-	out.ItemMap = make(map[string]struct{})
-
-	// Process items.
-	for _, item := range channel.Items {
-
+		if item.ID == "" {
+			item.ID = strings.TrimSpace(item.Identifier)
+		}
 		if item.ID == "" {
 			link := extractLink(item.Link)
 			if link == "" {
-				if debug {
-					fmt.Printf("[w] Item %q has no ID or link and will be ignored.\n", item.Title)
-					fmt.Printf("[w] %#v\n", item)
-				}
-				warnings = true
-				continue
+				p.Drop(WarnMissingID, "guid", fmt.Sprintf("item %q has no ID or link", item.Title))
+				return nil
 			}
 			item.ID = link
 		}
 
-		// Skip items already known.
-		if _, ok := out.ItemMap[item.ID]; ok {
-			continue
-		}
-
 		next := new(Item)
 		next.Title = item.Title
-		next.Summary = item.Description
-		next.Content = item.Content
+		next.Summary = string(item.Description)
+		next.Content = string(item.Content)
+		// Descriptions are commonly HTML, though RSS does not say so.
+		next.SummaryType = contentType(next.Summary, ContentTypeHTML)
+		next.ContentType = contentType(next.Content, ContentTypeHTML)
 		next.Categories = item.Categories
 		next.Link = extractLink(item.Link)
+		next.Links = atomLinks(p, p.items, item.ID, item.AtomLinks)
 		next.Image = item.Image.Image()
-		if item.Date != "" {
-			next.Date, err = parseTime(item.Date)
-			if err == nil {
-				next.DateValid = true
-			}
-		} else if item.PubDate != "" {
-			next.Date, err = parseTime(item.PubDate)
-			if err == nil {
-				next.DateValid = true
-			}
-		}
+		next.Authors = appendPeople(nil, parsePerson(item.Author))
+		item.dcElements.item(next)
+		next.Podcast = item.podcastItem.item(p, item.ID)
+		p.itemDate(next, "date", item.Date)
+		p.itemDate(next, "pubDate", item.PubDate)
 		next.ID = item.ID
 
-		// Also convert `media:thumbnail` entries into enclosures
-		hasMedia := item.MediaContent != mrssContent{} || item.MediaThumbnail != mrssThumbnail{}
-
-		if len(item.Enclosures) > 0 || hasMedia {
-			encLen := len(item.Enclosures)
-			if hasMedia {
-				encLen += 1
-			}
-
-			next.Enclosures = make([]*Enclosure, encLen)
-			for i := range item.Enclosures {
-				next.Enclosures[i] = item.Enclosures[i].Enclosure()
-			}
-
-			if hasMedia {
-				next.Enclosures[len(item.Enclosures)] = item.MediaThumbnail.ToEnclosure()
-			}
+		for i := range item.Enclosures {
+			next.Enclosures = append(next.Enclosures, item.Enclosures[i].Enclosure(p, item.ID))
+		}
+		// Media RSS objects, and their thumbnails, are enclosures too.
+		next.Media = item.mrssElements.media(p, item.ID)
+		next.Enclosures = item.mrssElements.enclosures(p, item.ID, next.Enclosures, next.Media)
+		if next.Media != nil && next.Image.URL == "" && len(next.Media.Thumbnails) > 0 {
+			image := *next.Media.Thumbnails[0]
+			next.Image = &image
+		}
+		if next.ITunes = item.itunesItem.item(p, item.ID); next.ITunes != nil {
+			next.ITunes.fill(next)
 		}
 		next.Read = false
 
-		out.Items = append(out.Items, next)
-		out.ItemMap[next.ID] = struct{}{}
-		out.Unread++
+		// Items are resolved against the link of the channel.
+		p.itemBase = extractLink(feed.Channel.Link)
+		return p.Emit(next)
 	}
 
-	if warnings && debug {
-		fmt.Printf("[i] Encountered warnings:\n%s\n", data)
+	err = p.decodeXML(r, &feed, nsRSS, rssSpaces)
+	if err != nil {
+		return nil, err
+	}
+	if feed.Channel.XMLName.Local == "" {
+		return nil, &ParseError{Format: p.format.String(), Err: errors.New("no channel found")}
+	}
+
+	channel := feed.Channel
+
+	out := p.out
+	out.Title = channel.Title
+	out.Language = channel.Language
+	out.Authors = appendPeople(nil, parsePerson(channel.ManagingEditor))
+	out.Authors = appendPeople(out.Authors, parsePerson(channel.Author))
+	out.Description = string(channel.Description)
+	out.Rights = strings.TrimSpace(channel.Copyright)
+	out.Categories = channel.Categories.toArray()
+	channel.dcElements.feed(out)
+	out.Author = channel.Author
+	out.Link = extractLink(channel.Link)
+	out.Links = atomLinks(p, -1, "", channel.AtomLinks)
+	out.SelfURL = linkHref(out.Links, "self")
+	out.Hubs = hubLinks(out.Links)
+	out.NextURL = linkHref(out.Links, "next")
+	out.Image = channel.Image.Image()
+	if out.ITunes = channel.itunesChannel.feed(); out.ITunes != nil {
+		out.ITunes.fill(out)
+	}
+	out.Podcast = channel.podcastChannel.feed(p)
+	if out.Author == "" {
+		out.Author = authorName(out.Authors)
 	}
 
+	// Where the feed declares both, it is not polled sooner than either
+	// asks.
+	out.Refresh = RefreshLatest.combine(
+		ttlRefresh(channel.MinsToLive, channel.SkipHours, channel.SkipDays),
+		channel.syElements.refresh(p),
+	)
+
 	return out, nil
 }
 
@@ -146,12 +122,15 @@ func extractLink(links []rss20Link) string {
 
 type rss20Feed struct {
 	XMLName xml.Name      `xml:"rss"`
-	Channel *rss20Channel `xml:"channel"`
+	Channel *rss20Channel `xml:"urn:rss channel"`
 }
 
+// rss20Category is a category given as text, or as an attribute as
+// iTunes does.
 type rss20Category struct {
-	XMLName xml.Name `xml:"category"`
-	Name    string   `xml:"text,attr"`
+	XMLName  xml.Name `xml:"category"`
+	Name     string   `xml:"text,attr"`
+	Chardata string   `xml:",chardata"`
 }
 
 type rss20CategorySlice []rss20Category
@@ -164,23 +143,35 @@ func (r rss20CategorySlice) toArray() (result []string) {
 	result = make([]string, count)
 	for i := range r {
 		result[i] = r[i].Name
+		if result[i] == "" {
+			result[i] = strings.TrimSpace(r[i].Chardata)
+		}
 	}
 	return
 }
 
 type rss20Channel struct {
 	XMLName     xml.Name           `xml:"channel"`
-	Title       string             `xml:"title"`
-	Language    string             `xml:"language"`
-	Author      string             `xml:"author"`
-	Description string             `xml:"description"`
-	Link        []rss20Link        `xml:"link"`
-	Image       rss20Image         `xml:"image"`
-	Categories  rss20CategorySlice `xml:"category"`
-	Items       []rss20item        `xml:"item"`
-	MinsToLive  int                `xml:"ttl"`
-	SkipHours   []int              `xml:"skipHours>hour"`
-	SkipDays    []string           `xml:"skipDays>day"`
+	Title       string             `xml:"urn:rss title"`
+	Language    string             `xml:"urn:rss language"`
+	Author      string             `xml:"urn:rss author"`
+	Description rssText            `xml:"urn:rss description"`
+	Link        []rss20Link        `xml:"urn:rss link"`
+	AtomLinks   []atomLink         `xml:"http://www.w3.org/2005/Atom link"`
+	Image       rss20Image         `xml:"urn:rss image"`
+	Categories  rss20CategorySlice `xml:"urn:rss category"`
+	Items       elementFunc        `xml:"urn:rss item"`
+	MinsToLive  int                `xml:"urn:rss ttl"`
+	SkipHours   []int              `xml:"urn:rss skipHours>hour"`
+	SkipDays    []string           `xml:"urn:rss skipDays>day"`
+	Copyright   string             `xml:"urn:rss copyright"`
+
+	// The managing editor is a person, usually written as "email (Name)".
+	ManagingEditor string `xml:"urn:rss managingEditor"`
+	dcElements
+	syElements
+	itunesChannel
+	podcastChannel
 }
 
 type rss20Link struct {
@@ -194,104 +185,52 @@ type rss20Categories []string
 
 type rss20item struct {
 	XMLName     xml.Name        `xml:"item"`
-	Title       string          `xml:"title"`
-	Description string          `xml:"description"`
-	Content     string          `xml:"encoded"`
-	Categories  rss20Categories `xml:"category"`
-	PubDate     string          `xml:"pubDate"`
-	Date        string          `xml:"date"`
-	Image       rss20Image      `xml:"image"`
-	Link        []rss20Link     `xml:"link"`
+	Title       string          `xml:"urn:rss title"`
+	Description rssText         `xml:"urn:rss description"`
+	Content     rssText         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Categories  rss20Categories `xml:"urn:rss category"`
+	PubDate     string          `xml:"urn:rss pubDate"`
+	Image       rss20Image      `xml:"urn:rss image"`
+	Link        []rss20Link     `xml:"urn:rss link"`
+	AtomLinks   []atomLink      `xml:"http://www.w3.org/2005/Atom link"`
+	Author      string          `xml:"urn:rss author"`
 	DateValid   bool
-	ID          string           `xml:"guid"`
-	Enclosures  []rss20Enclosure `xml:"enclosure"`
-	// Support for Yahoo Media RSS, see https://www.rssboard.org/media-rss.
-	MediaGroup     mrssGroup     `xml:"http://search.yahoo.com/mrss/ group"`
-	MediaContent   mrssContent   `xml:"http://search.yahoo.com/mrss/ content"`
-	MediaThumbnail mrssThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	ID          string           `xml:"urn:rss guid"`
+	Enclosures  []rss20Enclosure `xml:"urn:rss enclosure"`
+	dcElements
+	itunesItem
+	podcastItem
+	mrssElements
 }
 
 type rss20Enclosure struct {
 	XMLName xml.Name `xml:"enclosure"`
 	URL     string   `xml:"url,attr"`
 	Type    string   `xml:"type,attr"`
-	Length  uint     `xml:"length,attr"`
+	Length  string   `xml:"length,attr"` // Often malformed, so parsed leniently.
 }
 
-func (r *rss20Enclosure) Enclosure() *Enclosure {
+func (r *rss20Enclosure) Enclosure(p *Parser, id string) *Enclosure {
 	out := new(Enclosure)
-	out.URL = r.URL
-	out.Type = r.Type
-	out.Length = r.Length
+	out.URL = strings.TrimSpace(r.URL)
+	out.Type = strings.TrimSpace(r.Type)
+	out.Length = uint(p.parseInt(p.items, id, "enclosure", r.Length))
 	return out
 }
 
 type rss20Image struct {
 	XMLName xml.Name `xml:"image"`
-	Href    string   `xml:"href,attr"`
-	Title   string   `xml:"title"`
-	URL     string   `xml:"url"`
-	Height  int      `xml:"height"`
-	Width   int      `xml:"width"`
+	Title   string   `xml:"urn:rss title"`
+	URL     string   `xml:"urn:rss url"`
+	Height  int      `xml:"urn:rss height"`
+	Width   int      `xml:"urn:rss width"`
 }
 
 func (i *rss20Image) Image() *Image {
 	out := new(Image)
 	out.Title = i.Title
-	out.Href = i.Href
 	out.URL = i.URL
 	out.Height = uint32(i.Height)
 	out.Width = uint32(i.Width)
 	return out
 }
-
-// See https://www.rssboard.org/media-rss#media-group for details.
-type mrssGroup struct {
-	Contents []mrssContent `xml:"http://search.yahoo.com/mrss/ content"`
-}
-
-// See https://www.rssboard.org/media-rss#media-content for details.
-type mrssContent struct {
-	URL          string `xml:"url,attr"`
-	FileSize     uint   `xml:"fileSize,attr"`
-	Type         string `xml:"type,attr"`
-	Medium       string `xml:"medium,attr"`
-	IsDefault    string `xml:"isDefault,attr"`
-	Expression   string `xml:"expression,attr"`
-	Bitrate      uint   `xml:"bitrate,attr"`
-	Framerate    uint   `xml:"framerate,attr"`
-	SamplingRate uint   `xml:"samplingrate,attr"`
-	Channels     uint   `xml:"channels,attr"`
-	Duration     uint   `xml:"duration,attr"`
-	Height       uint   `xml:"height,attr"`
-	Width        uint   `xml:"width,attr"`
-	Lang         string `xml:"lang,attr"`
-}
-
-func (r *mrssContent) ToEnclosure() *Enclosure {
-	out := new(Enclosure)
-	out.URL = r.URL
-	out.Type = r.Type
-	out.Length = r.FileSize
-	return out
-}
-
-// See https://www.rssboard.org/media-rss#media-thumbnails for details.
-type mrssThumbnail struct {
-	URL    string `xml:"url,attr"`
-	Height uint   `xml:"height,attr"`
-	Width  uint   `xml:"width,attr"`
-	Time   string `xml:"time,attr"`
-}
-
-func (r *mrssThumbnail) ToEnclosure() *Enclosure {
-	ext := r.URL[strings.LastIndex(r.URL, ".")+1:]
-
-	out := new(Enclosure)
-	out.URL = r.URL
-	// This is a heuristic since the MIME type isn't actually specified
-	out.Type = fmt.Sprintf("image/%s", ext)
-	// This is incorrect since the length (in bytes) is not specified
-	out.Length = 0
-	return out
-}
@@ -200,8 +200,8 @@ func assertEqual(expected, got string, t *testing.T) {
 
 func TestParseMultipleLinks(t *testing.T) {
 	tests := map[string]string{
-		"rss_2.0_links_single":   "link_a",
-		"rss_2.0_links_multiple": "link_b",
+		"rss_2.0_links_single":   "https://www.nytimes.com/section/link_a",
+		"rss_2.0_links_multiple": "https://www.nytimes.com/section/link_b",
 	}
 
 	for test, want := range tests {
@@ -224,7 +224,7 @@ func TestParseMultipleLinks(t *testing.T) {
 
 func TestRss2_0ParseMediaThumbnail(t *testing.T) {
 	tests := map[string][]string{
-		"rss_2.0_media_thumbnail": {"http://example.com/image.jpg", "image/jpg"},
+		"rss_2.0_media_thumbnail": {"http://example.com/image.jpg", "image/jpeg"},
 	}
 
 	for test, want := range tests {
@@ -249,5 +249,9 @@ func TestRss2_0ParseMediaThumbnail(t *testing.T) {
 			t.Errorf("%s: got %q, want %q", name, enc.Type, want[1])
 		}
 
+		if !enc.TypeGuessed {
+			t.Errorf("%s: expected the type to be marked as guessed", name)
+		}
+
 	}
-}
\ No newline at end of file
+}
@@ -1,14 +1,20 @@
 package rss
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseTitle(t *testing.T) {
@@ -45,7 +51,7 @@ func TestEnclosure(t *testing.T) {
 		"rss_1.0":   Enclosure{URL: "http://foo.bar/baz.mp3", Type: "audio/mpeg", Length: 65535},
 		"rss_2.0":   Enclosure{URL: "http://example.com/file.mp3", Type: "audio/mpeg", Length: 65535},
 		"rss_2.0-1": Enclosure{URL: "http://gdb.voanews.com/6C49CA6D-C18D-414D-8A51-2B7042A81010_cx0_cy29_cw0_w800_h450.jpg", Type: "image/jpeg", Length: 3123},
-		"atom_1.0":  Enclosure{URL: "http://example.org/audio.mp3", Type: "audio/mpeg", Length: 1234},
+		"atom_1.0":  Enclosure{URL: "http://example.org/audio.mp3", Type: "audio/mpeg", Title: "MP3", Length: 1234},
 	}
 
 	for test, want := range tests {
@@ -159,3 +165,436 @@ func TestItemGUIDs(t *testing.T) {
 		t.Errorf("Expected two items in feed 'rssupdate' after step 2, got %v", len(feed2.Items))
 	}
 }
+
+func TestConditionalUpdate(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/rssupdate-1")
+	if err != nil {
+		t.Fatalf("Reading testdata 'rssupdate-1': %v", err)
+	}
+
+	const etag = `"v1"`
+	requests, conditional := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	feed, err := FetchByClient(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+
+	if feed.ETag != etag {
+		t.Errorf("Expected ETag %q, got %q", etag, feed.ETag)
+	}
+
+	if err := feed.Update(); err != nil {
+		t.Fatalf("Failed updating feed: %v", err)
+	}
+
+	if requests != 2 || conditional != 1 {
+		t.Errorf("Expected one conditional request out of two, got %d of %d", conditional, requests)
+	}
+
+	if len(feed.Items) != 1 || feed.Unread != 1 {
+		t.Errorf("Expected one item after 304, got %d (%d unread)", len(feed.Items), feed.Unread)
+	}
+
+	if !feed.Refresh.After(time.Now()) {
+		t.Errorf("Expected refresh in the future after 304, got %v", feed.Refresh)
+	}
+}
+
+func TestFetchSendsConditionalHeaders(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/rssupdate-1")
+	if err != nil {
+		t.Fatalf("Reading testdata 'rssupdate-1': %v", err)
+	}
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	feed, err := Fetch(server.URL)
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+	if feed.FetchFunc == nil || feed.RequestFunc == nil {
+		t.Error("Expected Fetch to set FetchFunc and RequestFunc")
+	}
+
+	feed.Refresh = time.Time{}
+	if err := feed.Update(); err != nil {
+		t.Fatalf("Failed updating feed: %v", err)
+	}
+
+	if got := header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("Expected If-None-Match %q, got %q", `"v1"`, got)
+	}
+	if got := header.Get("If-Modified-Since"); got != "Mon, 02 Jan 2006 15:04:05 GMT" {
+		t.Errorf("Expected If-Modified-Since, got %q", got)
+	}
+}
+
+func TestNotModifiedKeepsFeedInterval(t *testing.T) {
+	const data = `<rss version="2.0"><channel><title>Often</title><ttl>5</ttl>
+		<item><guid>1</guid></item></channel></rss>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	feed, err := FetchByClient(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("Fetching: %v", err)
+	}
+	if feed.FeedInterval != 5*time.Minute {
+		t.Errorf("Expected feed interval of 5m, got %v", feed.FeedInterval)
+	}
+
+	feed.Refresh = time.Time{}
+	if err := feed.Update(); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+	if until := time.Until(feed.Refresh); until <= 0 || until > 5*time.Minute {
+		t.Errorf("Expected refresh within 5m after 304, got %v", until)
+	}
+	if feed.FeedInterval != 5*time.Minute {
+		t.Errorf("Expected feed interval of 5m to be kept, got %v", feed.FeedInterval)
+	}
+}
+
+func TestUpdateUnchangedBody(t *testing.T) {
+	feed, err := FetchByFunc(MakeTestdataFetchFunc("rssupdate-1"), "http://localhost/dummyrss")
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+
+	if feed.ContentHash == "" {
+		t.Fatal("Expected content hash to be set")
+	}
+
+	feed.Title = "Changed"
+	if err := feed.Update(); err != nil {
+		t.Fatalf("Failed updating feed: %v", err)
+	}
+
+	// An unchanged body is not parsed, so the title is not reset.
+	if feed.Title != "Changed" {
+		t.Errorf("Expected unchanged body to be skipped, got title %q", feed.Title)
+	}
+}
+
+func TestUpdateUnchangedBodyNotParsed(t *testing.T) {
+	body := `<rss version="2.0"><channel><title>Feed</title></channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClient(server.Client()); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+
+	// A body that is not a feed fails to parse, so it is only accepted if
+	// its hash is compared first.
+	body = "not a feed"
+	sum := sha256.Sum256([]byte(body))
+	feed.ContentHash = hex.EncodeToString(sum[:])
+	feed.Refresh = time.Time{}
+	if err := feed.UpdateByClient(server.Client()); err != nil {
+		t.Errorf("Expected the unchanged body not to be parsed, got %v", err)
+	}
+}
+
+func TestFetchContextCancel(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := FetchByClientContext(ctx, server.URL, server.Client()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded from FetchByClientContext, got %v", err)
+	}
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClientContext(ctx, server.Client()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded from UpdateByClientContext, got %v", err)
+	}
+
+	enc := &Enclosure{URL: server.URL}
+	if _, err := enc.GetContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded from Enclosure.GetContext, got %v", err)
+	}
+}
+
+func TestFetchByFuncContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	fetch := MakeTestdataFetchFunc("rssupdate-1")
+	feed, err := FetchByFuncContext(ctx, func(ctx context.Context, url string) (*http.Response, error) {
+		if ctx.Value(key{}) != "value" {
+			t.Error("Context was not passed to ContextFetchFunc")
+		}
+		return fetch(url)
+	}, "http://localhost/dummyrss")
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	feed.FetchFunc = fetch
+	feed.RequestFunc = nil
+	if err := feed.UpdateContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelled update, got %v", err)
+	}
+}
+
+func TestPermanentRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/old", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/rssupdate-1")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	defer func(threshold int) { PermanentRedirectThreshold = threshold }(PermanentRedirectThreshold)
+	PermanentRedirectThreshold = 2
+
+	feed, err := FetchByClient(server.URL+"/old", server.Client())
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+
+	if feed.UpdateURL != server.URL+"/old" || feed.RedirectCount != 1 {
+		t.Errorf("Expected one pending redirect, got UpdateURL %q with count %d", feed.UpdateURL, feed.RedirectCount)
+	}
+
+	feed.Refresh = time.Time{}
+	if err := feed.Update(); err != nil {
+		t.Fatalf("Failed updating feed: %v", err)
+	}
+
+	if feed.UpdateURL != server.URL+"/new" || feed.RedirectCount != 0 {
+		t.Errorf("Expected UpdateURL to follow redirect, got %q with count %d", feed.UpdateURL, feed.RedirectCount)
+	}
+
+	feed, err = FetchByClient(server.URL+"/temporary", server.Client())
+	if err != nil {
+		t.Fatalf("Failed fetching testdata 'rssupdate-1': %v", err)
+	}
+
+	if feed.RedirectURL != "" {
+		t.Errorf("Expected temporary redirect to be ignored, got %q", feed.RedirectURL)
+	}
+}
+
+func TestUpdateGone(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClient(server.Client()); !errors.Is(err, ErrGone) {
+		t.Fatalf("Expected ErrGone, got %v", err)
+	}
+
+	if !feed.Gone {
+		t.Error("Expected feed to be marked as gone")
+	}
+
+	feed.Refresh = time.Now().Add(time.Hour)
+
+	if err := feed.UpdateByClient(server.Client()); !errors.Is(err, ErrGone) || requests != 1 {
+		t.Errorf("Expected ErrGone without a request, got %v after %d requests", err, requests)
+	}
+}
+
+func TestUpdateExpired(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/feed+json")
+		w.Write([]byte(`{"version": "https://jsonfeed.org/version/1.1", "title": "Old", "expired": true, "items": []}`))
+	}))
+	defer server.Close()
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClient(server.Client()); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+
+	if !feed.Expired {
+		t.Error("Expected feed to be marked as expired")
+	}
+
+	// Expiry is reported even before the feed is due for a refresh.
+	if err := feed.UpdateByClient(server.Client()); !errors.Is(err, ErrExpired) || requests != 1 {
+		t.Errorf("Expected ErrExpired without a request, got %v after %d requests", err, requests)
+	}
+}
+
+func TestUpdateIcons(t *testing.T) {
+	icon := "one"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/feed+json")
+		fmt.Fprintf(w, `{"version": "https://jsonfeed.org/version/1.1", "title": "Icons", "icon": "https://blog.example/%s.png", "favicon": "https://blog.example/%s.ico", "items": []}`, icon, icon)
+	}))
+	defer server.Close()
+
+	feed := &Feed{UpdateURL: server.URL}
+	if err := feed.UpdateByClient(server.Client()); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+
+	icon = "two"
+	feed.Refresh = time.Time{}
+	if err := feed.UpdateByClient(server.Client()); err != nil {
+		t.Fatalf("Updating: %v", err)
+	}
+	if feed.Image == nil || feed.Image.URL != "https://blog.example/two.png" {
+		t.Errorf("Expected the new icon, got %+v", feed.Image)
+	}
+	if feed.Favicon != "https://blog.example/two.ico" {
+		t.Errorf("Expected the new favicon, got %q", feed.Favicon)
+	}
+}
+
+func TestSelfLinkMismatch(t *testing.T) {
+	tests := map[string]bool{
+		"http://rss.golem.de/rss.php?feed=ATOM1.0":    false,
+		"HTTP://rss.golem.de:80/rss.php?feed=ATOM1.0": false,
+		"https://rss.golem.de/rss.php?feed=ATOM1.0":   true,
+		"http://example.com/feeds/golem.xml":          true,
+	}
+
+	for url, want := range tests {
+		feed, err := FetchByFunc(MakeTestdataFetchFunc("atom_1.0-1"), url)
+		if err != nil {
+			t.Fatalf("Failed fetching testdata 'atom_1.0-1': %v", err)
+		}
+
+		if got := feed.SelfLinkMismatch(); got != want {
+			t.Errorf("%s: got mismatch %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestParseItems(t *testing.T) {
+	tests := map[string]int{
+		"rss_1.0":     40,
+		"rss_2.0":     2,
+		"atom_1.0":    1,
+		"jsonfeed_v1": 1,
+	}
+
+	for test, want := range tests {
+		name := filepath.Join("testdata", test)
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		var items []*Item
+		feed, err := ParseItems(f, func(item *Item) error {
+			items = append(items, item)
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", name, err)
+		}
+
+		if len(items) != want {
+			t.Errorf("%s: got %d items, want %d", name, len(items), want)
+		}
+
+		if len(feed.Items) != 0 {
+			t.Errorf("%s: expected items not to be stored, got %d", name, len(feed.Items))
+		}
+
+		if feed.Title == "" {
+			t.Errorf("%s: expected feed title to be parsed", name)
+		}
+	}
+}
+
+func TestParseItemsStop(t *testing.T) {
+	errStop := errors.New("stop")
+
+	for _, test := range []string{"rss_1.0", "rss_2.0", "atom_1.0", "jsonfeed_v1"} {
+		name := filepath.Join("testdata", test)
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("Reading %s: %v", name, err)
+		}
+
+		calls := 0
+		_, err = ParseItems(f, func(item *Item) error {
+			calls++
+			return errStop
+		})
+		f.Close()
+
+		if err != errStop || calls != 1 {
+			t.Errorf("%s: expected to stop after one item, got %v after %d calls", name, err, calls)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	name := filepath.Join("testdata", "rss_2.0-1")
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Reading %s: %v", name, err)
+	}
+	defer f.Close()
+
+	feed, err := ParseReader(f)
+	if err != nil {
+		t.Fatalf("Parsing %s: %v", name, err)
+	}
+
+	if len(feed.Items) != 4 {
+		t.Errorf("%s: got %d items, want 4", name, len(feed.Items))
+	}
+}
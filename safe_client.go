@@ -0,0 +1,237 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBlocked is matched by errors.Is when a safe client refuses to fetch a
+// URL. See NewSafeClient.
+var ErrBlocked = errors.New("blocked by safe client")
+
+// SafeOptions configures a client returned by NewSafeClient.
+type SafeOptions struct {
+	// Schemes that may be fetched. Defaults to http and https.
+	Schemes []string
+
+	// Ports that may be connected to. Defaults to 80 and 443.
+	Ports []int
+
+	// AllowNetworks are exempt from the address checks, so that known
+	// internal services can still be fetched.
+	AllowNetworks []*net.IPNet
+
+	// AllowHosts are host names exempt from the address checks.
+	AllowHosts []string
+
+	// MaxRedirects is the number of redirects followed. Defaults to 10.
+	MaxRedirects int
+}
+
+// blockedNetworks are special-purpose ranges that are neither private nor
+// loopback, but still must not be reachable from a feed URL.
+var blockedNetworks = parseCIDRs(
+	"0.0.0.0/8",       // "This" network.
+	"100.64.0.0/10",   // Carrier-grade NAT.
+	"192.0.0.0/24",    // IETF protocol assignments.
+	"192.0.2.0/24",    // Documentation.
+	"198.18.0.0/15",   // Benchmarking.
+	"198.51.100.0/24", // Documentation.
+	"203.0.113.0/24",  // Documentation.
+	"240.0.0.0/4",     // Reserved, and broadcast.
+	"64:ff9b::/96",    // IPv4/IPv6 translation.
+	"64:ff9b:1::/48",  // Local IPv4/IPv6 translation.
+	"2001:db8::/32",   // Documentation.
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	out := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		out[i] = network
+	}
+	return out
+}
+
+// NewSafeClient returns a http.Client for fetching untrusted URLs. It
+// refuses to connect to loopback, private, link-local and other
+// non-public addresses, and to schemes and ports outside opts.
+//
+// Addresses are checked after DNS resolution, and the connection is made
+// to the checked address, so a host name cannot be re-resolved to a
+// different address in between. Every redirect is checked the same way.
+// The client does not use a proxy.
+//
+// A nil opts uses the defaults described in SafeOptions.
+func NewSafeClient(opts *SafeOptions) *http.Client {
+	s := &safeDialer{
+		dialer:  &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		schemes: []string{"http", "https"},
+		ports:   []int{80, 443},
+	}
+	maxRedirects := 10
+	if opts != nil {
+		if len(opts.Schemes) > 0 {
+			s.schemes = opts.Schemes
+		}
+		if len(opts.Ports) > 0 {
+			s.ports = opts.Ports
+		}
+		if opts.MaxRedirects > 0 {
+			maxRedirects = opts.MaxRedirects
+		}
+		s.allowNetworks = opts.AllowNetworks
+		s.allowHosts = opts.AllowHosts
+	}
+
+	transport := &http.Transport{
+		DialContext:           s.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: &safeTransport{s: s, next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// safeTransport checks the scheme and port of every request, including
+// each redirect, before it is sent.
+type safeTransport struct {
+	s    *safeDialer
+	next http.RoundTripper
+}
+
+func (t *safeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.s.checkURL(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+type safeDialer struct {
+	dialer        *net.Dialer
+	schemes       []string
+	ports         []int
+	allowNetworks []*net.IPNet
+	allowHosts    []string
+}
+
+func (s *safeDialer) checkURL(req *http.Request) error {
+	allowed := false
+	for _, scheme := range s.schemes {
+		if strings.EqualFold(req.URL.Scheme, scheme) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlocked, req.URL.Scheme)
+	}
+
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if strings.EqualFold(req.URL.Scheme, "https") {
+			port = "443"
+		}
+	}
+	return s.checkPort(port)
+}
+
+func (s *safeDialer) checkPort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err == nil {
+		for _, allowed := range s.ports {
+			if n == allowed {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: port %s is not allowed", ErrBlocked, port)
+}
+
+func (s *safeDialer) checkIP(ip net.IP) error {
+	for _, network := range s.allowNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: address %s is not public", ErrBlocked, ip)
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: address %s is not public", ErrBlocked, ip)
+		}
+	}
+	return nil
+}
+
+func (s *safeDialer) allowedHost(host string) bool {
+	for _, allowed := range s.allowHosts {
+		if strings.EqualFold(strings.TrimSuffix(host, "."), strings.TrimSuffix(allowed, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// DialContext resolves addr, checks each of its addresses, and connects to
+// the first one that is allowed and reachable.
+func (s *safeDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPort(port); err != nil {
+		return nil, err
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedHost := s.allowedHost(host)
+	err = fmt.Errorf("%w: no addresses found for %s", ErrBlocked, host)
+	for _, ip := range ips {
+		if !allowedHost {
+			if checkErr := s.checkIP(ip.IP); checkErr != nil {
+				err = checkErr
+				continue
+			}
+		}
+
+		var conn net.Conn
+		conn, err = s.dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
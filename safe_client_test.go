@@ -0,0 +1,110 @@
+package rss
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func serverPort(t *testing.T, server *httptest.Server) int {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Parsing server URL: %v", err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatalf("Parsing server port: %v", err)
+	}
+	return port
+}
+
+func TestSafeClientBlocksLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/rssupdate-1")
+	}))
+	defer server.Close()
+
+	port := serverPort(t, server)
+
+	client := NewSafeClient(&SafeOptions{Ports: []int{port}})
+	if _, err := FetchByClient(server.URL, client); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Expected loopback to be blocked, got %v", err)
+	}
+
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	client = NewSafeClient(&SafeOptions{Ports: []int{port}, AllowNetworks: []*net.IPNet{loopback}})
+	if _, err := FetchByClient(server.URL, client); err != nil {
+		t.Errorf("Expected allowed network to be fetched, got %v", err)
+	}
+
+	client = NewSafeClient(&SafeOptions{Ports: []int{port}, AllowHosts: []string{"localhost"}})
+	localhost := "http://localhost:" + strconv.Itoa(port)
+	if _, err := FetchByClient(localhost, client); err != nil {
+		t.Errorf("Expected allowed host to be fetched, got %v", err)
+	}
+
+	enc := &Enclosure{URL: server.URL}
+	if _, err := enc.GetByClient(NewSafeClient(&SafeOptions{Ports: []int{port}})); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Expected enclosure on loopback to be blocked, got %v", err)
+	}
+}
+
+func TestSafeClientChecksRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/rssupdate-1")
+	}))
+	defer target.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	client := NewSafeClient(&SafeOptions{
+		Ports:         []int{serverPort(t, redirect)},
+		AllowNetworks: []*net.IPNet{loopback},
+	})
+
+	if _, err := FetchByClient(redirect.URL, client); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Expected redirect to another port to be blocked, got %v", err)
+	}
+}
+
+func TestSafeClientSchemes(t *testing.T) {
+	client := NewSafeClient(nil)
+	for _, u := range []string{"file:///etc/passwd", "ftp://example.com/feed", "gopher://example.com/"} {
+		if _, err := FetchByClient(u, client); !errors.Is(err, ErrBlocked) {
+			t.Errorf("%s: expected scheme to be blocked, got %v", u, err)
+		}
+	}
+}
+
+func TestSafeClientAddresses(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+	}
+
+	s := &safeDialer{}
+	for addr, want := range tests {
+		if got := s.checkIP(net.ParseIP(addr)) == nil; got != want {
+			t.Errorf("%s: got allowed %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -71,13 +71,26 @@ var TimeLayouts = []string{
 }
 
 func parseTime(s string) (time.Time, error) {
+	return parseTimeIn(s, nil, time.UTC)
+}
+
+// parseTimeIn parses s using layouts, then the package layouts. Dates that
+// do not give a time zone are taken to be in loc.
+func parseTimeIn(s string, layouts []string, loc *time.Location) (time.Time, error) {
 	s = strings.TrimSpace(s)
 
 	var e error
 	var t time.Time
 
+	for _, layout := range layouts {
+		t, e = time.ParseInLocation(layout, s, loc)
+		if e == nil {
+			return t, nil
+		}
+	}
+
 	for _, layout := range TimeLayouts {
-		t, e = time.Parse(layout, s)
+		t, e = time.ParseInLocation(layout, s, loc)
 		if e == nil {
 			return t, nil
 		}
@@ -97,10 +110,7 @@ func parseTime(s string) (time.Time, error) {
 		// inside an alpine docker container.
 		loc, err := time.LoadLocation(t.Location().String())
 		if err != nil {
-			if debug {
-				fmt.Printf("[w] could not load timezone: %q\n", e)
-			}
-			return t, err
+			return t, fmt.Errorf("loading time zone: %w", err)
 		}
 
 		t, e = time.ParseInLocation(layout, s, loc)
@@ -0,0 +1,100 @@
+package rss
+
+import (
+	"encoding/xml"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// resolveURL resolves ref against base. It returns ref unchanged if either
+// cannot be parsed, or there is no base.
+func resolveURL(base, ref string) string {
+	if base == "" || ref == "" {
+		return ref
+	}
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	b, err := url.Parse(strings.TrimSpace(base))
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(u).String()
+}
+
+var (
+	htmlStartTag = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+	htmlURLAttr  = regexp.MustCompile(`(?i)(\s(?:href|src|poster|cite)\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+// resolveHTML resolves the URLs of links and images in an HTML fragment
+// against base. Links to fragments of the same page are left alone.
+func resolveHTML(s, base string) string {
+	if base == "" || !strings.Contains(s, "<") {
+		return s
+	}
+	return htmlStartTag.ReplaceAllStringFunc(s, func(tag string) string {
+		return htmlURLAttr.ReplaceAllStringFunc(tag, func(attr string) string {
+			m := htmlURLAttr.FindStringSubmatch(attr)
+			value := m[2]
+			quote := ""
+			if value[0] == '"' || value[0] == '\'' {
+				quote, value = value[:1], value[1:len(value)-1]
+			}
+
+			ref := html.UnescapeString(value)
+			if ref == "" || strings.HasPrefix(ref, "#") {
+				return attr
+			}
+			if quote == "" {
+				quote = `"`
+			}
+			return m[1] + quote + html.EscapeString(resolveURL(base, ref)) + quote
+		})
+	})
+}
+
+// xmlBaseTracker implements XML Base. Every element inside one with an
+// xml:base attribute is given an xml:base attribute holding the URL in
+// effect, resolved against the ancestors' bases and the document's URL.
+// Elements can then be resolved without knowing their ancestors.
+type xmlBaseTracker struct {
+	t     xml.TokenReader
+	base  string   // The document's URL.
+	bases []string // The base in effect inside each open element.
+}
+
+func (b *xmlBaseTracker) Token() (xml.Token, error) {
+	tok, err := b.t.Token()
+	switch t := tok.(type) {
+	case xml.StartElement:
+		parent := b.base
+		if len(b.bases) > 0 {
+			parent = b.bases[len(b.bases)-1]
+		}
+
+		base, found := parent, false
+		attrs := make([]xml.Attr, 0, len(t.Attr)+1)
+		for _, a := range t.Attr {
+			if a.Name.Space == nsXML && a.Name.Local == "base" {
+				base, found = resolveURL(parent, a.Value), true
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		b.bases = append(b.bases, base)
+
+		if found || base != b.base {
+			t.Attr = append(attrs, xml.Attr{Name: xml.Name{Space: nsXML, Local: "base"}, Value: base})
+			tok = t
+		}
+	case xml.EndElement:
+		if len(b.bases) > 0 {
+			b.bases = b.bases[:len(b.bases)-1]
+		}
+	}
+	return tok, err
+}
@@ -0,0 +1,150 @@
+package rss
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveHTML(t *testing.T) {
+	base := "https://example.com/blog/post/"
+	tests := map[string]string{
+		`<a href="../other">x</a>`:                   `<a href="https://example.com/blog/other">x</a>`,
+		`<img src='/img.png' alt="a">`:               `<img src='https://example.com/img.png' alt="a">`,
+		`<img src=pic.jpg>`:                          `<img src="https://example.com/blog/post/pic.jpg">`,
+		`<a href="?a=1&amp;b=2">x</a>`:               `<a href="https://example.com/blog/post/?a=1&amp;b=2">x</a>`,
+		`<a href="#note">x</a>`:                      `<a href="#note">x</a>`,
+		`<a href="mailto:me@example.com">x</a>`:      `<a href="mailto:me@example.com">x</a>`,
+		`<video poster="p.jpg" src="v.mp4"></video>`: `<video poster="https://example.com/blog/post/p.jpg" src="https://example.com/blog/post/v.mp4"></video>`,
+		`href="text" outside a tag`:                  `href="text" outside a tag`,
+	}
+	for in, want := range tests {
+		if got := resolveHTML(in, base); got != want {
+			t.Errorf("resolveHTML(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+const xmlBaseFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="/blog/">
+<title>Base</title>
+<link href="./"/>
+<link rel="self" href="feed.xml"/>
+<entry xml:base="2018/">
+	<id>1</id>
+	<title>Nested</title>
+	<link href="post.html"/>
+	<link rel="enclosure" xml:base="/media/" href="audio.mp3" type="audio/mpeg"/>
+	<content type="html" xml:base="https://cdn.example/">&lt;img src="a.png"&gt;&lt;a href="/about"&gt;About&lt;/a&gt;</content>
+</entry>
+<entry>
+	<id>2</id>
+	<title>Feed base</title>
+	<link href="other.html"/>
+	<summary type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><a href="x">x</a></div></summary>
+</entry>
+</feed>`
+
+func TestAtomXMLBase(t *testing.T) {
+	for _, opts := range []*ParseOptions{
+		{BaseURL: "https://example.com/feeds/atom"},
+		{BaseURL: "https://example.com/feeds/atom", Lenient: true},
+		{BaseURL: "https://example.com/feeds/atom", Limits: &Limits{MaxDepth: 10}},
+	} {
+		feed, err := ParseWithOptions([]byte(xmlBaseFeed), opts)
+		if err != nil {
+			t.Fatalf("Parsing: %v", err)
+		}
+
+		if want := "https://example.com/blog/"; feed.Link != want {
+			t.Errorf("Expected feed link %q, got %q", want, feed.Link)
+		}
+		if want := "https://example.com/blog/feed.xml"; feed.SelfURL != want {
+			t.Errorf("Expected self URL %q, got %q", want, feed.SelfURL)
+		}
+		if len(feed.Items) != 2 {
+			t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+		}
+
+		item := feed.Items[0]
+		if want := "https://example.com/blog/2018/post.html"; item.Link != want {
+			t.Errorf("Expected item link %q, got %q", want, item.Link)
+		}
+		if len(item.Enclosures) != 1 || item.Enclosures[0].URL != "https://example.com/media/audio.mp3" {
+			t.Errorf("Expected enclosure resolved against its own xml:base, got %v", item.Enclosures)
+		}
+		if want := `<img src="https://cdn.example/a.png"><a href="https://cdn.example/about">About</a>`; item.Content != want {
+			t.Errorf("Expected content %q, got %q", want, item.Content)
+		}
+
+		item = feed.Items[1]
+		if want := "https://example.com/blog/other.html"; item.Link != want {
+			t.Errorf("Expected item link %q, got %q", want, item.Link)
+		}
+		if want := `<a href="https://example.com/blog/x">x</a>`; item.Summary != want {
+			t.Errorf("Expected summary %q, got %q", want, item.Summary)
+		}
+	}
+}
+
+const relativeRSSFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+<title>Relative</title>
+<link>https://example.com/blog/</link>
+<image><url>/logo.png</url></image>
+<item>
+	<guid>1</guid>
+	<link>posts/1</link>
+	<description>&lt;a href="posts/2"&gt;Next&lt;/a&gt;</description>
+	<enclosure url="media/1.mp3" length="1" type="audio/mpeg"/>
+</item>
+</channel>
+</rss>`
+
+func TestRSSRelativeLinks(t *testing.T) {
+	feed, err := ParseWithOptions([]byte(relativeRSSFeed), &ParseOptions{BaseURL: "https://feeds.example/relative.xml"})
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if want := "https://feeds.example/logo.png"; feed.Image.URL != want {
+		t.Errorf("Expected image %q, got %q", want, feed.Image.URL)
+	}
+	item := feed.Items[0]
+	if want := "https://example.com/blog/posts/1"; item.Link != want {
+		t.Errorf("Expected item link %q, got %q", want, item.Link)
+	}
+	if want := `<a href="https://example.com/blog/posts/2">Next</a>`; item.Summary != want {
+		t.Errorf("Expected summary %q, got %q", want, item.Summary)
+	}
+	if want := "https://example.com/blog/media/1.mp3"; item.Enclosures[0].URL != want {
+		t.Errorf("Expected enclosure %q, got %q", want, item.Enclosures[0].URL)
+	}
+}
+
+func TestFetchResolvesAgainstURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/old", http.RedirectHandler("/feeds/new.xml", http.StatusFound))
+	mux.HandleFunc("/feeds/new.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(optionsFeed))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	opts := &FetchOptions{RequestFunc: server.Client().Do}
+	feed, err := FetchWithOptions(server.URL+"/old", opts)
+	if err != nil {
+		t.Fatalf("Fetching: %v", err)
+	}
+
+	if want := server.URL + "/blog/"; feed.Link != want {
+		t.Errorf("Expected feed link %q, got %q", want, feed.Link)
+	}
+	if want := server.URL + "/blog/posts/1"; feed.Items[0].Link != want {
+		t.Errorf("Expected item link %q, got %q", want, feed.Items[0].Link)
+	}
+	if opts.BaseURL != "" {
+		t.Errorf("Expected options to be left alone, got BaseURL %q", opts.BaseURL)
+	}
+}
@@ -0,0 +1,121 @@
+package rss
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Warning codes.
+const (
+	WarnMissingID       = "missing-id"       // An item had no ID and was dropped.
+	WarnDuplicateID     = "duplicate-id"     // An item repeated an earlier ID and was dropped.
+	WarnItemLimit       = "item-limit"       // Items beyond ParseOptions.MaxItems were dropped.
+	WarnInvalidDate     = "invalid-date"     // A date could not be parsed.
+	WarnInvalidSchedule = "invalid-schedule" // An update schedule could not be understood.
+	WarnInvalidValue    = "invalid-value"    // Some other value could not be parsed.
+)
+
+// A Warning describes a problem with a feed that did not stop it being
+// parsed, such as an item that had to be dropped.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Item    int    `json:"item"`    // Index of the item in the document, or -1.
+	Element string `json:"element"` // Element or field concerned, if any.
+}
+
+func (w Warning) String() string {
+	if w.Item < 0 {
+		return fmt.Sprintf("%s: %s", w.Code, w.Message)
+	}
+	return fmt.Sprintf("item %d: %s: %s", w.Item, w.Code, w.Message)
+}
+
+// Warn records a problem with the feed as a whole.
+func (p *Parser) Warn(code, element, message string) {
+	p.warn(-1, "", code, element, message)
+}
+
+// WarnItem records a problem with the item being read, which is the next
+// to be passed to Emit or Drop.
+func (p *Parser) WarnItem(code, element, message string) {
+	p.warn(p.items, "", code, element, message)
+}
+
+// Drop records that the item being read is left out of the feed, and why.
+func (p *Parser) Drop(code, element, message string) {
+	p.drop("", code, element, message)
+}
+
+// drop is like Drop, but also logs the ID of the item, if known.
+func (p *Parser) drop(id, code, element, message string) {
+	p.warn(p.items, id, code, element, message)
+	p.items++
+}
+
+func (p *Parser) warn(item int, id, code, element, message string) {
+	p.out.Warnings = append(p.out.Warnings, Warning{Code: code, Message: message, Item: item, Element: element})
+
+	attrs := []interface{}{"code", code}
+	if item >= 0 {
+		attrs = append(attrs, "item", item)
+	}
+	if id != "" {
+		attrs = append(attrs, "item_id", id)
+	}
+	if element != "" {
+		attrs = append(attrs, "element", element)
+	}
+	p.log.Warn(message, attrs...)
+}
+
+// itemDate sets the date of item from the named element, unless it
+// already has one, recording a warning if the date cannot be parsed.
+func (p *Parser) itemDate(item *Item, element, date string) {
+	if date == "" || item.DateValid {
+		return
+	}
+	t, err := p.ParseTime(date)
+	if err != nil && !t.IsZero() {
+		p.warn(p.items, item.ID, WarnInvalidDate, element, fmt.Sprintf("date %q: %v", date, err))
+		return
+	}
+	if err != nil {
+		p.warn(p.items, item.ID, WarnInvalidDate, element, fmt.Sprintf("cannot parse date %q", date))
+		return
+	}
+	item.Date = t
+	item.DateValid = true
+}
+
+// parseInt parses a non-negative integer from the named element of the
+// given item, or the feed if item is -1. If it is invalid, a warning is
+// recorded and zero returned.
+func (p *Parser) parseInt(item int, id, element, s string) int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		p.warn(item, id, WarnInvalidValue, element, fmt.Sprintf("invalid %s %q", element, s))
+		return 0
+	}
+	return n
+}
+
+// parseFloat is like parseInt, for numbers that may have a fraction.
+func (p *Parser) parseFloat(item int, id, element, s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		p.warn(item, id, WarnInvalidValue, element, fmt.Sprintf("invalid %s %q", element, s))
+		return 0
+	}
+	return f
+}
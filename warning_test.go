@@ -0,0 +1,40 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+const warningFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+<title>Warnings</title>
+<item><guid>1</guid><title>First</title><pubDate>yesterday</pubDate></item>
+<item><title>Nameless</title></item>
+<item><guid>1</guid><title>Again</title></item>
+<item><guid>2</guid><title>Second</title></item>
+<item><guid>3</guid><title>Third</title></item>
+<item><guid>4</guid><title>Fourth</title></item>
+</channel>
+</rss>`
+
+func TestParseWarnings(t *testing.T) {
+	feed, err := ParseWithOptions([]byte(warningFeed), &ParseOptions{MaxItems: 2})
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(feed.Items))
+	}
+
+	want := []Warning{
+		{Code: WarnInvalidDate, Message: `cannot parse date "yesterday"`, Item: 0, Element: "pubDate"},
+		{Code: WarnMissingID, Message: `item "Nameless" has no ID or link`, Item: 1, Element: "guid"},
+		{Code: WarnDuplicateID, Message: `item "Again" repeats ID "1"`, Item: 2},
+		{Code: WarnItemLimit, Message: "items after the first 2 were dropped", Item: 4},
+	}
+	if !reflect.DeepEqual(feed.Warnings, want) {
+		t.Errorf("Expected warnings:\n%v\ngot:\n%v", want, feed.Warnings)
+	}
+}